@@ -0,0 +1,312 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+type ChatBotPermission string
+
+const (
+	ChatBotEveryone    ChatBotPermission = "everyone"
+	ChatBotSupporter   ChatBotPermission = "supporter"
+	ChatBotAllowedUser ChatBotPermission = "allowed_user"
+	ChatBotBroadcaster ChatBotPermission = "broadcaster"
+)
+
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// ChatBotCommand is registered with ChatBot.Register. Reply is a text/template executed with the
+// ChatBotRequest; Handler, when set, runs first and its result is available as .Result.
+type ChatBotCommand struct {
+	Name    string
+	Aliases []string
+	// Permission defaults to ChatBotEveryone. The broadcaster and AllowUserIds pass every check.
+	Permission   ChatBotPermission
+	AllowUserIds []string
+	MinArgs      int
+	// Usage is replied when fewer than MinArgs arguments are given. Usage replies have cooldowns of
+	// their own, of the same lengths, so they neither spam the chat nor hold back the command.
+	Usage        string
+	Cooldown     time.Duration
+	UserCooldown time.Duration
+	Reply        string
+	Handler      func(request *ChatBotRequest) (string, error)
+
+	reply         *template.Template
+	cooldown      chatBotCooldown
+	usageCooldown chatBotCooldown
+}
+
+type chatBotCooldown struct {
+	lastUsed time.Time
+	userUsed map[string]time.Time
+}
+
+type ChatBotRequest struct {
+	MovieId string
+	Comment Comment
+	Command string
+	Args    []string
+	Result  string
+}
+
+// ChatBot reads the comments of a movie with a CommentStream and answers "!command" comments.
+// Replies are posted with at least SendInterval between them, or through Queue when it is set.
+type ChatBot struct {
+	CommentService   *CommentService
+	SupporterService *SupporterService
+	MovieId          string
+	// BroadcasterId is the user id of the movie owner. It is used for permissions.
+	BroadcasterId string
+	// BotUserId is the user posting the replies. Its comments are ignored.
+	BotUserId         string
+	Prefix            string
+	Sns               CommentSns
+	SendInterval      time.Duration
+	Queue             *CommentQueue
+	SupporterCacheTTL time.Duration
+	UseBearerToken    bool
+
+	mu         sync.Mutex
+	commands   map[string]*ChatBotCommand
+	supporters map[string]supporterCacheEntry
+	lastSent   time.Time
+}
+
+type supporterCacheEntry struct {
+	isSupporting bool
+	expires      time.Time
+}
+
+func CreateChatBot(commentService *CommentService, supporterService *SupporterService, movieId string, broadcasterId string) *ChatBot {
+	return &ChatBot{
+		CommentService:    commentService,
+		SupporterService:  supporterService,
+		MovieId:           movieId,
+		BroadcasterId:     broadcasterId,
+		Prefix:            "!",
+		Sns:               CommentSnsNone,
+		SendInterval:      3 * time.Second,
+		SupporterCacheTTL: 10 * time.Minute,
+	}
+}
+
+// Register adds command under its name and aliases. Names are case-insensitive.
+func (chatBot *ChatBot) Register(command ChatBotCommand) error {
+	if command.Name == "" {
+		return errors.New("command name is empty")
+	}
+	if command.Reply == "" && command.Handler == nil {
+		return fmt.Errorf("command %v has neither reply nor handler", command.Name)
+	}
+	if command.Reply != "" {
+		reply, err := template.New(command.Name).Parse(command.Reply)
+		if err != nil {
+			return err
+		}
+		command.reply = reply
+	}
+	if command.Permission == "" {
+		command.Permission = ChatBotEveryone
+	}
+	command.cooldown.userUsed = map[string]time.Time{}
+	command.usageCooldown.userUsed = map[string]time.Time{}
+	chatBot.mu.Lock()
+	defer chatBot.mu.Unlock()
+	if chatBot.commands == nil {
+		chatBot.commands = map[string]*ChatBotCommand{}
+	}
+	for _, name := range append([]string{command.Name}, command.Aliases...) {
+		chatBot.commands[strings.ToLower(name)] = &command
+	}
+	return nil
+}
+
+// Run answers the comments posted after it started until ctx is done and returns ctx.Err().
+func (chatBot *ChatBot) Run(ctx context.Context) error {
+	logger := *chatBot.CommentService.Logger
+	stream := CreateCommentStream(chatBot.CommentService, chatBot.MovieId)
+	stream.UseBearerToken = chatBot.UseBearerToken
+	return stream.RunFunc(ctx, func(comment Comment) {
+		if _, err := chatBot.HandleComment(ctx, comment); err != nil {
+			logger.Warn("handle comment failed for ChatBot", comment.Id, err)
+		}
+	})
+}
+
+// HandleComment runs the command in comment, if any, and returns the reply that was posted.
+// Comments that are not commands, unknown commands, denied users and cooldowns return "" without error.
+func (chatBot *ChatBot) HandleComment(ctx context.Context, comment Comment) (string, error) {
+	logger := *chatBot.CommentService.Logger
+	if chatBot.BotUserId != "" && comment.FromUser.Id == chatBot.BotUserId {
+		return "", nil
+	}
+	name, args, ok, err := ParseChatBotCommand(comment.Message, chatBot.Prefix)
+	if !ok || err != nil {
+		return "", err
+	}
+	chatBot.mu.Lock()
+	command := chatBot.commands[strings.ToLower(name)]
+	chatBot.mu.Unlock()
+	if command == nil {
+		return "", nil
+	}
+	allowed, err := chatBot.permitted(command, comment.FromUser.Id)
+	if err != nil || !allowed {
+		return "", err
+	}
+	// the usage reply takes its own cooldown, so the corrected command runs right away
+	if len(args) < command.MinArgs {
+		if command.Usage == "" || !chatBot.takeCooldown(command, &command.usageCooldown, comment.FromUser.Id) {
+			return "", nil
+		}
+		return command.Usage, chatBot.send(ctx, command.Usage)
+	}
+	if !chatBot.takeCooldown(command, &command.cooldown, comment.FromUser.Id) {
+		logger.Debug("cooldown for ChatBot", command.Name, comment.FromUser.Id)
+		return "", nil
+	}
+
+	request := &ChatBotRequest{MovieId: chatBot.MovieId, Comment: comment, Command: name, Args: args}
+	var reply string
+	if command.Handler != nil {
+		if request.Result, err = command.Handler(request); err != nil {
+			return "", err
+		}
+		reply = request.Result
+	}
+	if command.reply != nil {
+		var builder strings.Builder
+		if err = command.reply.Execute(&builder, request); err != nil {
+			return "", err
+		}
+		reply = builder.String()
+	}
+	if reply == "" {
+		return "", nil
+	}
+	return reply, chatBot.send(ctx, reply)
+}
+
+func (chatBot *ChatBot) permitted(command *ChatBotCommand, userId string) (bool, error) {
+	if userId == chatBot.BroadcasterId || slices.Contains(command.AllowUserIds, userId) {
+		return true, nil
+	}
+	switch command.Permission {
+	case ChatBotEveryone:
+		return true, nil
+	case ChatBotSupporter:
+		return chatBot.isSupporter(userId)
+	default:
+		return false, nil
+	}
+}
+
+// isSupporter reports whether userId supports the broadcaster, cached for SupporterCacheTTL.
+func (chatBot *ChatBot) isSupporter(userId string) (bool, error) {
+	chatBot.mu.Lock()
+	entry, ok := chatBot.supporters[userId]
+	chatBot.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.isSupporting, nil
+	}
+	status, _, err := chatBot.SupporterService.GetSupportingStatus(userId, chatBot.BroadcasterId, chatBot.UseBearerToken)
+	if err != nil {
+		return false, err
+	}
+	chatBot.mu.Lock()
+	defer chatBot.mu.Unlock()
+	if chatBot.supporters == nil {
+		chatBot.supporters = map[string]supporterCacheEntry{}
+	}
+	chatBot.supporters[userId] = supporterCacheEntry{isSupporting: status.IsSupporting, expires: time.Now().Add(chatBot.SupporterCacheTTL)}
+	return status.IsSupporting, nil
+}
+
+// takeCooldown reports whether cooldown of command has passed for userId and starts it again.
+func (chatBot *ChatBot) takeCooldown(command *ChatBotCommand, cooldown *chatBotCooldown, userId string) bool {
+	chatBot.mu.Lock()
+	defer chatBot.mu.Unlock()
+	now := time.Now()
+	if now.Sub(cooldown.lastUsed) < command.Cooldown || now.Sub(cooldown.userUsed[userId]) < command.UserCooldown {
+		return false
+	}
+	cooldown.lastUsed = now
+	cooldown.userUsed[userId] = now
+	return true
+}
+
+// send posts message once SendInterval has passed since the previous reply.
+func (chatBot *ChatBot) send(ctx context.Context, message string) error {
+	if chatBot.Queue != nil {
+		_, err := chatBot.Queue.Send(ctx, chatBot.MovieId, message, chatBot.Sns)
+		return err
+	}
+	chatBot.mu.Lock()
+	wait := time.Until(chatBot.lastSent.Add(chatBot.SendInterval))
+	chatBot.lastSent = time.Now().Add(max(wait, 0))
+	chatBot.mu.Unlock()
+	if err := sleepContext(ctx, wait); err != nil {
+		return err
+	}
+	_, _, err := chatBot.CommentService.PostComment(chatBot.MovieId, message, string(chatBot.Sns))
+	return err
+}
+
+// ParseChatBotCommand splits a comment like `!quote add "hello world"` into its command name and
+// arguments. Arguments are separated by white space, including the full-width space, and can be
+// quoted with " or '. ok is false when message does not start with prefix.
+func ParseChatBotCommand(message string, prefix string) (name string, args []string, ok bool, err error) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, prefix) {
+		return "", nil, false, nil
+	}
+	var fields []string
+	var field strings.Builder
+	inField := false
+	var quote rune
+	escaped := false
+	for _, r := range strings.TrimPrefix(message, prefix) {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != 0:
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return "", nil, true, ErrUnterminatedQuote
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	if len(fields) == 0 || fields[0] == "" {
+		return "", nil, false, nil
+	}
+	return fields[0], fields[1:], true, nil
+}
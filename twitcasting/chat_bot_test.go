@@ -0,0 +1,180 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeChatServer struct {
+	mu             sync.Mutex
+	posted         []twitcasting.CommentRequestBody
+	supporters     map[string]bool
+	statusRequests int
+}
+
+func (fake *fakeChatServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, "/supporting_status") {
+		fake.statusRequests++
+		userId := strings.Split(r.URL.Path, "/")[2]
+		body, _ := json.Marshal(twitcasting.SupportingStatusContainer{IsSupporting: fake.supporters[userId]})
+		_, _ = w.Write(body)
+		return
+	}
+	requestBody := twitcasting.CommentRequestBody{}
+	_ = json.NewDecoder(r.Body).Decode(&requestBody)
+	fake.posted = append(fake.posted, requestBody)
+	w.WriteHeader(http.StatusCreated)
+	body, _ := json.Marshal(twitcasting.CommentContainer{MovieId: "100", Comment: twitcasting.Comment{Message: requestBody.Comment}})
+	_, _ = w.Write(body)
+}
+
+func (fake *fakeChatServer) messages() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var messages []string
+	for _, posted := range fake.posted {
+		messages = append(messages, posted.Comment)
+	}
+	return messages
+}
+
+func chatComment(userId string, name string, message string) twitcasting.Comment {
+	return twitcasting.Comment{Id: "1", Message: message, FromUser: twitcasting.User{Id: userId, Name: name}}
+}
+
+func TestParseChatBotCommand(t *testing.T) {
+	name, args, ok, err := twitcasting.ParseChatBotCommand(`!quote add "hello world" 'it\'s'　end`, "!")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "quote", name)
+	assert.Equal(t, []string{"add", "hello world", "it's", "end"}, args)
+
+	_, _, ok, _ = twitcasting.ParseChatBotCommand("hello !quote", "!")
+	assert.False(t, ok)
+	_, _, ok, _ = twitcasting.ParseChatBotCommand("! ", "!")
+	assert.False(t, ok)
+
+	_, _, _, err = twitcasting.ParseChatBotCommand(`!say "oops`, "!")
+	assert.Equal(t, twitcasting.ErrUnterminatedQuote, err)
+}
+
+func TestChatBot(t *testing.T) {
+	fake := &fakeChatServer{supporters: map[string]bool{"fan": true}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	bot := twitcasting.CreateChatBot(locator.Comment, locator.Supporter, "100", "owner")
+	bot.BotUserId = "bot"
+	bot.SendInterval = 0
+
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "hello", Aliases: []string{"hi"}, Reply: "Hello {{.Comment.FromUser.Name}}!", UserCooldown: time.Hour}))
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{
+		Name:       "dice",
+		Permission: twitcasting.ChatBotSupporter,
+		MinArgs:    1,
+		Usage:      "usage: !dice <sides>",
+		Handler: func(request *twitcasting.ChatBotRequest) (string, error) {
+			return request.Args[0], nil
+		},
+		Reply: "rolled {{.Result}}",
+	}))
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "fail", Handler: func(request *twitcasting.ChatBotRequest) (string, error) {
+		return "", errors.New("failed")
+	}}))
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "end", Permission: twitcasting.ChatBotBroadcaster, Reply: "bye"}))
+	assert.NotNil(t, bot.Register(twitcasting.ChatBotCommand{Name: "broken", Reply: "{{.Missing"}))
+
+	ctx := context.Background()
+	reply, err := bot.HandleComment(ctx, chatComment("viewer", "Alice", "!HI"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello Alice!", reply)
+	// user cooldown
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!hello"))
+	assert.Equal(t, "", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("bot", "Bot", "!hello"))
+	assert.Equal(t, "", reply)
+
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!dice 6"))
+	assert.Equal(t, "", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("fan", "Fan", "!dice"))
+	assert.Equal(t, "usage: !dice <sides>", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("fan", "Fan", "!dice 6"))
+	assert.Equal(t, "rolled 6", reply)
+	assert.Equal(t, 2, fake.statusRequests)
+
+	_, err = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!fail"))
+	assert.NotNil(t, err)
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!end"))
+	assert.Equal(t, "", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("owner", "Owner", "!end"))
+	assert.Equal(t, "bye", reply)
+
+	assert.Equal(t, []string{"Hello Alice!", "usage: !dice <sides>", "rolled 6", "bye"}, fake.messages())
+	assert.Equal(t, "none", fake.posted[0].Sns)
+}
+
+func TestChatBotUsageSkipsCooldown(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	bot := twitcasting.CreateChatBot(locator.Comment, locator.Supporter, "100", "owner")
+	bot.SendInterval = 0
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "so", MinArgs: 1, Usage: "usage: !so <user>", Reply: "check {{index .Args 0}}", UserCooldown: time.Hour}))
+
+	ctx := context.Background()
+	reply, _ := bot.HandleComment(ctx, chatComment("viewer", "Alice", "!so"))
+	assert.Equal(t, "usage: !so <user>", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!so bob"))
+	assert.Equal(t, "check bob", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!so carol"))
+	assert.Equal(t, "", reply)
+}
+
+func TestChatBotUsageCooldown(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	bot := twitcasting.CreateChatBot(locator.Comment, locator.Supporter, "100", "owner")
+	bot.SendInterval = 0
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "so", MinArgs: 1, Usage: "usage: !so <user>", Reply: "check {{index .Args 0}}", UserCooldown: time.Hour}))
+
+	ctx := context.Background()
+	reply, _ := bot.HandleComment(ctx, chatComment("viewer", "Alice", "!so"))
+	assert.Equal(t, "usage: !so <user>", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("viewer", "Alice", "!so"))
+	assert.Equal(t, "", reply)
+	reply, _ = bot.HandleComment(ctx, chatComment("other", "Bob", "!so"))
+	assert.Equal(t, "usage: !so <user>", reply)
+	assert.Equal(t, 2, len(fake.messages()))
+}
+
+func TestChatBotSendInterval(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	bot := twitcasting.CreateChatBot(locator.Comment, locator.Supporter, "100", "owner")
+	bot.SendInterval = 30 * time.Millisecond
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "ping", Reply: "pong"}))
+
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := bot.HandleComment(context.Background(), chatComment("viewer", "Alice", "!ping"))
+		assert.Nil(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(started), 60*time.Millisecond)
+	assert.Equal(t, 3, len(fake.messages()))
+}
@@ -38,8 +38,22 @@ type CommentService ServiceBase
 
 // GetComments https://apiv2-doc.twitcasting.tv/#get-comments
 func (commentService *CommentService) GetComments(movieId string, limit int, offset int, useBearerToken bool) (*CommentListContainer, *ErrorResponse, error) {
+	return commentService.GetCommentsWith(GetCommentsRequest{MovieId: movieId, Limit: limit, Offset: offset, UseBearerToken: useBearerToken})
+}
+
+// GetCommentsBySliceId https://apiv2-doc.twitcasting.tv/#get-comments
+func (commentService *CommentService) GetCommentsBySliceId(movieId string, limit int, sliceId string, useBearerToken bool) (*CommentListContainer, *ErrorResponse, error) {
+	return commentService.GetCommentsWith(GetCommentsRequest{MovieId: movieId, Limit: limit, SliceId: sliceId, UseBearerToken: useBearerToken})
+}
+
+// GetCommentsWith https://apiv2-doc.twitcasting.tv/#get-comments
+func (commentService *CommentService) GetCommentsWith(request GetCommentsRequest) (*CommentListContainer, *ErrorResponse, error) {
 	logger := *commentService.Logger
-	response, err := commentService.Client.get(fmt.Sprintf("/movies/%v/comments?limit=%v&offset=%v", movieId, limit, offset), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetComments", err)
+		return nil, nil, err
+	}
+	response, err := commentService.Client.get(fmt.Sprintf("/movies/%v/comments?%v", request.MovieId, request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for GetComments", err)
 		return nil, nil, err
@@ -66,36 +80,6 @@ func (commentService *CommentService) GetComments(movieId string, limit int, off
 	}
 }
 
-// GetCommentsBySliceId https://apiv2-doc.twitcasting.tv/#get-comments
-func (commentService *CommentService) GetCommentsBySliceId(movieId string, limit int, sliceId string, useBearerToken bool) (*CommentListContainer, *ErrorResponse, error) {
-	logger := *commentService.Logger
-	response, err := commentService.Client.get(fmt.Sprintf("/movies/%v/comments?limit=%v&slice_id=%v", movieId, limit, sliceId), useBearerToken)
-	if err != nil {
-		logger.Error("request failed for GetCommentsBySliceId", err)
-		return nil, nil, err
-	}
-	defer commentService.Client.BodyClose(response.Body)
-	if response.StatusCode == 200 {
-		req := new(CommentListContainer)
-		err = json.NewDecoder(response.Body).Decode(req)
-		if err != nil {
-			logger.Error("decode response body failed for GetCommentsBySliceId", err)
-			return nil, nil, err
-		}
-		logger.Debug("response for GetCommentsBySliceId", req)
-		return req, nil, nil
-	} else {
-		req := new(ErrorResponse)
-		err = json.NewDecoder(response.Body).Decode(req)
-		if err != nil {
-			logger.Error("decode error response body failed for GetCommentsBySliceId", err)
-			return nil, nil, err
-		}
-		logger.Debug("error response for GetCommentsBySliceId", req)
-		return nil, req, errors.New("error response")
-	}
-}
-
 // PostComment @see https://apiv2-doc.twitcasting.tv/#post-comment
 // PostComment Requests can only be made using a Bearer Token.
 func (commentService *CommentService) PostComment(movieId string, message string, sns string) (*CommentContainer, *ErrorResponse, error) {
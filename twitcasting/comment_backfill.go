@@ -0,0 +1,150 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CommentSink receives the comments collected by BackfillComments. Calls are never concurrent.
+type CommentSink interface {
+	WriteComments(comments []Comment) error
+}
+
+// CommentSinkFunc adapts a function to CommentSink.
+type CommentSinkFunc func(comments []Comment) error
+
+func (commentSinkFunc CommentSinkFunc) WriteComments(comments []Comment) error {
+	return commentSinkFunc(comments)
+}
+
+type CommentBackfillOptions struct {
+	Concurrency    int
+	MaxRetries     int
+	RetryInterval  time.Duration
+	Progress       func(fetched int, allCount int)
+	UseBearerToken bool
+}
+
+type CommentBackfillResult struct {
+	AllCount int `json:"all_count"`
+	Fetched  int `json:"fetched"`
+}
+
+// ErrIncompleteBackfill is returned by BackfillComments when fewer comments than AllCount could be fetched.
+var ErrIncompleteBackfill = errors.New("comment backfill incomplete")
+
+// commentBackfillSweeps is the number of sequential sweeps BackfillComments makes when AllCount changes.
+const commentBackfillSweeps = 3
+
+// BackfillComments writes every comment of movieId into sink with offset paging. Pages may arrive
+// out of order, but each comment is written once. When AllCount changes during the walk, pages may
+// have shifted, so the comments are swept again one page at a time until AllCount holds still.
+// Fetched can exceed AllCount when comments are deleted during the backfill. A walk that still
+// fetches fewer than AllCount comments returns the partial result with ErrIncompleteBackfill.
+func (commentService *CommentService) BackfillComments(ctx context.Context, movieId string, sink CommentSink, options CommentBackfillOptions) (*CommentBackfillResult, error) {
+	logger := *commentService.Logger
+	if options.Concurrency == 0 {
+		options.Concurrency = 4
+	}
+	if options.RetryInterval == 0 {
+		options.RetryInterval = time.Second
+	}
+	var mu sync.Mutex
+	seen := map[string]struct{}{}
+	result := &CommentBackfillResult{}
+	changed := false
+	var firstErr error
+	// write stores the unseen comments of a page and notes a change of AllCount.
+	write := func(page *CommentListContainer) {
+		mu.Lock()
+		defer mu.Unlock()
+		if page.AllCount != result.AllCount {
+			changed = true
+			result.AllCount = page.AllCount
+		}
+		var unseen []Comment
+		for _, comment := range page.Comments {
+			if _, ok := seen[comment.Id]; !ok {
+				seen[comment.Id] = struct{}{}
+				unseen = append(unseen, comment)
+			}
+		}
+		if len(unseen) == 0 || firstErr != nil {
+			return
+		}
+		if err := sink.WriteComments(unseen); err != nil {
+			firstErr = err
+			return
+		}
+		result.Fetched += len(unseen)
+		if options.Progress != nil {
+			options.Progress(result.Fetched, result.AllCount)
+		}
+	}
+	fetch := func(offset int) (*CommentListContainer, error) {
+		var page *CommentListContainer
+		err := retry(ctx, options.MaxRetries, options.RetryInterval, func() error {
+			var err error
+			page, _, err = commentService.GetComments(movieId, commentsPageLimit, offset, options.UseBearerToken)
+			return err
+		})
+		return page, err
+	}
+
+	first, err := fetch(0)
+	if err != nil {
+		return nil, err
+	}
+	result.AllCount = first.AllCount
+	write(first)
+	if firstErr != nil {
+		return result, firstErr
+	}
+
+	var offsets []int
+	for offset := commentsPageLimit; offset < result.AllCount; offset += commentsPageLimit {
+		offsets = append(offsets, offset)
+	}
+	runConcurrently(ctx, len(offsets), options.Concurrency, func(i int) {
+		page, err := fetch(offsets[i])
+		if err != nil {
+			logger.Error("get comments failed for BackfillComments", offsets[i], err)
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+			return
+		}
+		write(page)
+	})
+	if firstErr != nil {
+		return result, firstErr
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	for sweep := 0; sweep < commentBackfillSweeps && (changed || result.Fetched < result.AllCount); sweep++ {
+		changed = false
+		for offset := 0; offset < result.AllCount; offset += commentsPageLimit {
+			page, err := fetch(offset)
+			if err != nil {
+				logger.Error("get comments failed for BackfillComments", offset, err)
+				return result, err
+			}
+			write(page)
+			if firstErr != nil {
+				return result, firstErr
+			}
+		}
+	}
+	if changed || result.Fetched < result.AllCount {
+		return result, fmt.Errorf("%w: fetched %d of %d comments", ErrIncompleteBackfill, result.Fetched, result.AllCount)
+	}
+	logger.Debug("result for BackfillComments", result)
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package twitcasting_test
+
+import (
+	"context"
+	"errors"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type collectingCommentSink struct {
+	comments []twitcasting.Comment
+}
+
+func (sink *collectingCommentSink) WriteComments(comments []twitcasting.Comment) error {
+	sink.comments = append(sink.comments, comments...)
+	return nil
+}
+
+func (sink *collectingCommentSink) sortedIds() []int {
+	var ids []int
+	for _, comment := range sink.comments {
+		id, _ := strconv.Atoi(comment.Id)
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sequence(from int, to int) []int {
+	var ids []int
+	for id := from; id <= to; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestBackfillComments(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(230)
+	failed := false
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.URL.Query().Get("offset") == "100" && !failed {
+			failed = true
+			mu.Unlock()
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"code":500,"message":"Internal Server Error"}}`))
+			return
+		}
+		mu.Unlock()
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	sink := &collectingCommentSink{}
+	var progress [][2]int
+	result, err := locator.Comment.BackfillComments(context.Background(), "100", sink, twitcasting.CommentBackfillOptions{
+		Concurrency:   3,
+		MaxRetries:    2,
+		RetryInterval: time.Millisecond,
+		Progress: func(fetched int, allCount int) {
+			progress = append(progress, [2]int{fetched, allCount})
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, failed)
+	assert.Equal(t, &twitcasting.CommentBackfillResult{AllCount: 230, Fetched: 230}, result)
+	assert.Equal(t, sequence(1, 230), sink.sortedIds())
+	assert.Equal(t, 5, len(progress))
+	assert.Equal(t, [2]int{230, 230}, progress[4])
+}
+
+func TestBackfillCommentsRejectedOffset(t *testing.T) {
+	fake := &fakeCommentsServer{maxOffset: 100}
+	fake.post(230)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	sink := &collectingCommentSink{}
+	result, err := locator.Comment.BackfillComments(context.Background(), "100", sink, twitcasting.CommentBackfillOptions{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 230, result.AllCount)
+	assert.Less(t, result.Fetched, 230)
+}
+
+func TestBackfillCommentsDeletedDuringWalk(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(230)
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the pages after offset 150 shift by one, which would skip comment 80
+		if r.URL.Query().Get("offset") == "150" && !deleted {
+			deleted = true
+			fake.remove("200")
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	sink := &collectingCommentSink{}
+	result, err := locator.Comment.BackfillComments(context.Background(), "100", sink, twitcasting.CommentBackfillOptions{Concurrency: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, &twitcasting.CommentBackfillResult{AllCount: 229, Fetched: 230}, result)
+	assert.Equal(t, sequence(1, 230), sink.sortedIds())
+}
+
+func TestBackfillCommentsKeepsChanging(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(60)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.post(1)
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	_, err := locator.Comment.BackfillComments(context.Background(), "100", &collectingCommentSink{}, twitcasting.CommentBackfillOptions{})
+	assert.True(t, errors.Is(err, twitcasting.ErrIncompleteBackfill))
+}
+
+func TestBackfillCommentsSinkError(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(120)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	sinkErr := errors.New("disk full")
+	_, err := locator.Comment.BackfillComments(context.Background(), "100", twitcasting.CommentSinkFunc(func(comments []twitcasting.Comment) error {
+		return sinkErr
+	}), twitcasting.CommentBackfillOptions{})
+	assert.Equal(t, sinkErr, err)
+}
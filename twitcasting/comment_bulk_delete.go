@@ -0,0 +1,129 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// CommentPredicate selects the comments deleted by DeleteCommentsWhere.
+type CommentPredicate func(comment Comment) bool
+
+func CommentsFromUsers(userIds ...string) CommentPredicate {
+	return func(comment Comment) bool {
+		return slices.Contains(userIds, comment.FromUser.Id)
+	}
+}
+
+func CommentsMatching(pattern *regexp.Regexp) CommentPredicate {
+	return func(comment Comment) bool {
+		return pattern.MatchString(comment.Message)
+	}
+}
+
+// CommentsBetween selects the comments posted in [from, to). A zero time leaves that side open.
+func CommentsBetween(from time.Time, to time.Time) CommentPredicate {
+	return func(comment Comment) bool {
+		created := time.Unix(int64(comment.Created), 0)
+		return (from.IsZero() || !created.Before(from)) && (to.IsZero() || created.Before(to))
+	}
+}
+
+// AllCommentPredicates selects the comments matching every predicate.
+func AllCommentPredicates(predicates ...CommentPredicate) CommentPredicate {
+	return func(comment Comment) bool {
+		for _, predicate := range predicates {
+			if !predicate(comment) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type BulkDeleteOptions struct {
+	Concurrency int
+	// DryRun reports the matching comments without deleting them.
+	DryRun bool
+	// Backfill configures how the comments are collected. Its UseBearerToken applies to reading only,
+	// deleting always uses the Bearer Token.
+	Backfill CommentBackfillOptions
+}
+
+type BulkDeleteResult struct {
+	Comment       Comment        `json:"comment"`
+	Deleted       bool           `json:"deleted"`
+	ErrorResponse *ErrorResponse `json:"error_response,omitempty"`
+	Error         string         `json:"error,omitempty"`
+}
+
+type BulkDeleteReport struct {
+	MovieId string             `json:"movie_id"`
+	DryRun  bool               `json:"dry_run"`
+	Scanned int                `json:"scanned"`
+	Matched int                `json:"matched"`
+	Deleted int                `json:"deleted"`
+	Failed  int                `json:"failed"`
+	Results []BulkDeleteResult `json:"results"`
+}
+
+// DeleteCommentsWhere deletes every comment of movieId selected by predicate. Deletions run with
+// bounded concurrency and wait for the rate limit to reset when it is exhausted.
+// The report lists the matched comments oldest first. When the backfill is incomplete because the
+// comments kept changing, as on a live, the comments matched so far are still deleted and the
+// report is returned together with ErrIncompleteBackfill.
+func (commentService *CommentService) DeleteCommentsWhere(ctx context.Context, movieId string, predicate CommentPredicate, options BulkDeleteOptions) (*BulkDeleteReport, error) {
+	logger := *commentService.Logger
+	if options.Concurrency == 0 {
+		options.Concurrency = 2
+	}
+	report := &BulkDeleteReport{MovieId: movieId, DryRun: options.DryRun}
+	var matched []Comment
+	_, err := commentService.BackfillComments(ctx, movieId, CommentSinkFunc(func(comments []Comment) error {
+		report.Scanned += len(comments)
+		for _, comment := range comments {
+			if predicate(comment) {
+				matched = append(matched, comment)
+			}
+		}
+		return nil
+	}), options.Backfill)
+	if err != nil && !errors.Is(err, ErrIncompleteBackfill) {
+		return nil, err
+	}
+	backfillErr := err
+	matched = chronologicalComments(matched)
+	report.Matched = len(matched)
+	report.Results = make([]BulkDeleteResult, len(matched))
+	for i, comment := range matched {
+		report.Results[i].Comment = comment
+	}
+	if options.DryRun {
+		return report, backfillErr
+	}
+
+	runConcurrently(ctx, len(matched), options.Concurrency, func(i int) {
+		result := &report.Results[i]
+		err := commentService.Client.waitRateLimit(ctx)
+		if err == nil {
+			_, result.ErrorResponse, err = commentService.DeleteComment(movieId, result.Comment.Id)
+		}
+		if err != nil {
+			logger.Warn("delete comment failed for DeleteCommentsWhere", result.Comment.Id, err)
+			result.Error = err.Error()
+			return
+		}
+		result.Deleted = true
+	})
+	for _, result := range report.Results {
+		if result.Deleted {
+			report.Deleted++
+		} else {
+			report.Failed++
+		}
+	}
+	logger.Debug("report for DeleteCommentsWhere", report.Matched, report.Deleted, report.Failed)
+	return report, errors.Join(backfillErr, ctx.Err())
+}
@@ -0,0 +1,134 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeleteCommentsWhere(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(60)
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		if r.Method != http.MethodDelete {
+			fake.ServeHTTP(w, r)
+			return
+		}
+		commentId := path.Base(r.URL.Path)
+		if commentId == "31" {
+			w.WriteHeader(http.StatusForbidden)
+			body, _ := json.Marshal(twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 403, Message: "Forbidden"}})
+			_, _ = w.Write(body)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, commentId)
+		mu.Unlock()
+		fake.remove(commentId)
+		body, _ := json.Marshal(twitcasting.DeleteCommentContainer{CommentId: commentId})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	// comments of user1 posted in [1700000030, 1700000040) whose message ends with an odd digit
+	predicate := twitcasting.AllCommentPredicates(
+		twitcasting.CommentsFromUsers("user1"),
+		twitcasting.CommentsMatching(regexp.MustCompile(`[13579]$`)),
+		twitcasting.CommentsBetween(time.Unix(1700000030, 0), time.Unix(1700000040, 0)),
+	)
+
+	report, err := locator.Comment.DeleteCommentsWhere(context.Background(), "100", predicate, twitcasting.BulkDeleteOptions{DryRun: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 60, report.Scanned)
+	assert.Equal(t, 2, report.Matched)
+	assert.Equal(t, 0, report.Deleted)
+	assert.Empty(t, deleted)
+
+	report, err = locator.Comment.DeleteCommentsWhere(context.Background(), "100", predicate, twitcasting.BulkDeleteOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "31", report.Results[0].Comment.Id)
+	assert.False(t, report.Results[0].Deleted)
+	assert.Equal(t, 403, report.Results[0].ErrorResponse.Error.Code)
+	assert.Equal(t, "37", report.Results[1].Comment.Id)
+	assert.True(t, report.Results[1].Deleted)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, []string{"37"}, deleted)
+
+	rateLimit, ok := locator.Comment.Client.RateLimit()
+	assert.True(t, ok)
+	assert.Equal(t, twitcasting.RateLimit{Limit: 60, Remaining: 59, Reset: rateLimit.Reset}, rateLimit)
+}
+
+func TestDeleteCommentsWhereRateLimit(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(3)
+	reset := time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+	var deletedAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			fake.ServeHTTP(w, r)
+			return
+		}
+		deletedAt = append(deletedAt, time.Now())
+		body, _ := json.Marshal(twitcasting.DeleteCommentContainer{CommentId: path.Base(r.URL.Path)})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	report, err := locator.Comment.DeleteCommentsWhere(context.Background(), "100", twitcasting.CommentsFromUsers("user1"), twitcasting.BulkDeleteOptions{Concurrency: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Deleted)
+	assert.False(t, deletedAt[0].Before(reset))
+}
+
+func TestDeleteCommentsWhereDuringLive(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(60)
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			// all_count grows between requests, so the backfill never settles
+			fake.post(1)
+			fake.ServeHTTP(w, r)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, path.Base(r.URL.Path))
+		mu.Unlock()
+		body, _ := json.Marshal(twitcasting.DeleteCommentContainer{CommentId: path.Base(r.URL.Path)})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	report, err := locator.Comment.DeleteCommentsWhere(context.Background(), "100", twitcasting.CommentsFromUsers("user1"), twitcasting.BulkDeleteOptions{})
+	assert.True(t, errors.Is(err, twitcasting.ErrIncompleteBackfill))
+	if assert.NotNil(t, report) {
+		assert.Greater(t, report.Matched, 0)
+		assert.Equal(t, report.Matched, report.Deleted)
+		assert.Equal(t, 0, report.Failed)
+		assert.Len(t, deleted, report.Deleted)
+	}
+}
@@ -0,0 +1,174 @@
+package twitcasting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteCommentsJSONLines writes one JSON encoded comment per line.
+func WriteCommentsJSONLines(w io.Writer, comments []Comment) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, comment := range comments {
+		if err := encoder.Encode(comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCommentsCSV writes comments oldest first with their offset from the start of movie.
+func WriteCommentsCSV(w io.Writer, movie Movie, comments []Comment) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"id", "created", "offset_seconds", "user_id", "user_screen_id", "user_name", "message"})
+	if err != nil {
+		return err
+	}
+	for _, comment := range chronologicalComments(comments) {
+		err = writer.Write([]string{
+			comment.Id,
+			time.Unix(int64(comment.Created), 0).UTC().Format(time.RFC3339),
+			strconv.Itoa(comment.Created - movie.Created),
+			comment.FromUser.Id,
+			comment.FromUser.ScreenId,
+			comment.FromUser.Name,
+			comment.Message,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCommentsSRT writes a SubRip subtitle track. Each comment is shown for cueDuration from
+// its offset to Movie.Created.
+func WriteCommentsSRT(w io.Writer, movie Movie, comments []Comment, cueDuration time.Duration) error {
+	for i, comment := range chronologicalComments(comments) {
+		start := commentOffset(movie, comment)
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s: %s\n\n",
+			i+1,
+			formatCueTime(start, ","),
+			formatCueTime(start+cueDuration, ","),
+			commentUserName(comment),
+			strings.ReplaceAll(comment.Message, "\n", " "),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCommentsWebVTT writes a WebVTT subtitle track, timed like WriteCommentsSRT.
+func WriteCommentsWebVTT(w io.Writer, movie Movie, comments []Comment, cueDuration time.Duration) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\n", " ")
+	for _, comment := range chronologicalComments(comments) {
+		start := commentOffset(movie, comment)
+		_, err := fmt.Fprintf(w, "%s\n%s --> %s\n<v %s>%s\n\n",
+			comment.Id,
+			formatCueTime(start, "."),
+			formatCueTime(start+cueDuration, "."),
+			escape.Replace(commentUserName(comment)),
+			escape.Replace(comment.Message),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var commentsHtmlTemplate = template.Must(template.New("comments").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>{{.Movie.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+li { list-style: none; display: flex; gap: .5em; align-items: flex-start; margin: .5em 0; }
+img { width: 32px; height: 32px; border-radius: 50%; }
+time { color: #888; font-size: .8em; }
+</style>
+</head>
+<body>
+<h1><a href="{{.Movie.Link}}">{{.Movie.Title}}</a></h1>
+<ul>
+{{- range .Comments}}
+<li id="comment-{{.Id}}"><img src="{{.Image}}" alt=""><div><strong>{{.Name}}</strong> <time>{{.Offset}}</time><p>{{.Message}}</p></div></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// WriteCommentsHTML writes a standalone HTML transcript with the icons from User.Image.
+func WriteCommentsHTML(w io.Writer, movie Movie, comments []Comment) error {
+	type htmlComment struct {
+		Id      string
+		Image   string
+		Name    string
+		Offset  string
+		Message string
+	}
+	data := struct {
+		Movie    Movie
+		Comments []htmlComment
+	}{Movie: movie}
+	for _, comment := range chronologicalComments(comments) {
+		offset := formatCueTime(commentOffset(movie, comment), ".")
+		data.Comments = append(data.Comments, htmlComment{
+			Id:      comment.Id,
+			Image:   comment.FromUser.Image,
+			Name:    commentUserName(comment),
+			Offset:  offset[:len(offset)-4],
+			Message: comment.Message,
+		})
+	}
+	return commentsHtmlTemplate.Execute(w, data)
+}
+
+func chronologicalComments(comments []Comment) []Comment {
+	sorted := append([]Comment(nil), comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Created != sorted[j].Created {
+			return sorted[i].Created < sorted[j].Created
+		}
+		return compareIds(sorted[i].Id, sorted[j].Id) < 0
+	})
+	return sorted
+}
+
+func commentOffset(movie Movie, comment Comment) time.Duration {
+	return time.Duration(max(comment.Created-movie.Created, 0)) * time.Second
+}
+
+func commentUserName(comment Comment) string {
+	if comment.FromUser.Name != "" {
+		return comment.FromUser.Name
+	}
+	return comment.FromUser.ScreenId
+}
+
+// formatCueTime formats d as hh:mm:ss<separator>mmm.
+func formatCueTime(d time.Duration, separator string) string {
+	milliseconds := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		milliseconds/3600000,
+		milliseconds/60000%60,
+		milliseconds/1000%60,
+		separator,
+		milliseconds%1000,
+	)
+}
@@ -0,0 +1,58 @@
+package twitcasting_test
+
+import (
+	"bytes"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportMovie = twitcasting.Movie{Id: "100", Title: "こんばんは", Link: "https://twitcasting.tv/user/movie/100", Created: 1700000000}
+
+var exportComments = []twitcasting.Comment{
+	{Id: "3", Message: "<b>bold</b> & more", FromUser: twitcasting.User{Id: "2", ScreenId: "bob", Image: "https://example.com/bob.png"}, Created: 1700003725},
+	{Id: "1", Message: "こんにちは", FromUser: twitcasting.User{Id: "1", ScreenId: "alice", Name: "Alice", Image: "https://example.com/alice.png"}, Created: 1700000001},
+}
+
+func TestWriteCommentsJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, twitcasting.WriteCommentsJSONLines(&buf, exportComments))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], `{"id":"3","message":"<b>bold</b> & more"`))
+}
+
+func TestWriteCommentsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, twitcasting.WriteCommentsCSV(&buf, exportMovie, exportComments))
+	assert.Equal(t, "id,created,offset_seconds,user_id,user_screen_id,user_name,message\n"+
+		"1,2023-11-14T22:13:21Z,1,1,alice,Alice,こんにちは\n"+
+		"3,2023-11-14T23:15:25Z,3725,2,bob,,<b>bold</b> & more\n", buf.String())
+}
+
+func TestWriteCommentsSRT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, twitcasting.WriteCommentsSRT(&buf, exportMovie, exportComments, 5*time.Second))
+	assert.Equal(t, "1\n00:00:01,000 --> 00:00:06,000\nAlice: こんにちは\n\n"+
+		"2\n01:02:05,000 --> 01:02:10,000\nbob: <b>bold</b> & more\n\n", buf.String())
+}
+
+func TestWriteCommentsWebVTT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, twitcasting.WriteCommentsWebVTT(&buf, exportMovie, exportComments, 2500*time.Millisecond))
+	assert.Equal(t, "WEBVTT\n\n"+
+		"1\n00:00:01.000 --> 00:00:03.500\n<v Alice>こんにちは\n\n"+
+		"3\n01:02:05.000 --> 01:02:07.500\n<v bob>&lt;b&gt;bold&lt;/b&gt; &amp; more\n\n", buf.String())
+}
+
+func TestWriteCommentsHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, twitcasting.WriteCommentsHTML(&buf, exportMovie, exportComments))
+	html := buf.String()
+	assert.Contains(t, html, "<title>こんばんは</title>")
+	assert.Contains(t, html, `<li id="comment-1"><img src="https://example.com/alice.png" alt=""><div><strong>Alice</strong> <time>00:00:01</time><p>こんにちは</p></div></li>`)
+	assert.Contains(t, html, "<p>&lt;b&gt;bold&lt;/b&gt; &amp; more</p>")
+	assert.Less(t, strings.Index(html, "comment-1"), strings.Index(html, "comment-3"))
+}
@@ -0,0 +1,320 @@
+package twitcasting
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// CommentQuery filters CommentIndex.Search. Zero fields match everything.
+type CommentQuery struct {
+	// Text is matched as a substring, ignoring case and full-width ASCII.
+	Text    string
+	UserIds []string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+type CommentUserStat struct {
+	User  User      `json:"user"`
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+type CommentWordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type CommentMinuteCount struct {
+	Minute time.Time `json:"minute"`
+	Count  int       `json:"count"`
+}
+
+// CommentIndex is an in-memory full-text index of comments. Text is indexed as character
+// unigrams and bigrams so Japanese, which has no spaces between words, can be searched.
+// The zero value is ready to use.
+type CommentIndex struct {
+	mu       sync.RWMutex
+	comments []Comment
+	ids      map[string]struct{}
+	postings map[string][]int
+	sorted   bool
+}
+
+// AddContainer adds the comments of a GetComments or GetCommentsBySliceId response.
+func (commentIndex *CommentIndex) AddContainer(container *CommentListContainer) {
+	commentIndex.Add(container.Comments...)
+}
+
+// Add indexes comments. Comments already in the index are ignored.
+func (commentIndex *CommentIndex) Add(comments ...Comment) {
+	commentIndex.mu.Lock()
+	defer commentIndex.mu.Unlock()
+	if commentIndex.ids == nil {
+		commentIndex.ids = map[string]struct{}{}
+	}
+	for _, comment := range comments {
+		if _, ok := commentIndex.ids[comment.Id]; ok {
+			continue
+		}
+		commentIndex.ids[comment.Id] = struct{}{}
+		commentIndex.comments = append(commentIndex.comments, comment)
+		commentIndex.sorted = false
+	}
+}
+
+func (commentIndex *CommentIndex) Len() int {
+	commentIndex.mu.RLock()
+	defer commentIndex.mu.RUnlock()
+	return len(commentIndex.comments)
+}
+
+// Search returns the comments matching query, oldest first.
+func (commentIndex *CommentIndex) Search(query CommentQuery) []Comment {
+	defer commentIndex.lockBuilt()()
+	text := normalizeCommentText(strings.TrimSpace(query.Text))
+	candidates := commentIndex.candidates(text)
+	var found []Comment
+	for _, i := range candidates {
+		comment := commentIndex.comments[i]
+		if len(query.UserIds) > 0 && !slices.Contains(query.UserIds, comment.FromUser.Id) {
+			continue
+		}
+		created := time.Unix(int64(comment.Created), 0)
+		if (!query.From.IsZero() && created.Before(query.From)) || (!query.To.IsZero() && !created.Before(query.To)) {
+			continue
+		}
+		if text != "" && !strings.Contains(normalizeCommentText(comment.Message), text) {
+			continue
+		}
+		found = append(found, comment)
+		if query.Limit > 0 && len(found) >= query.Limit {
+			break
+		}
+	}
+	return found
+}
+
+// UserStats returns the number of comments per user, most active first.
+func (commentIndex *CommentIndex) UserStats() []CommentUserStat {
+	defer commentIndex.lockBuilt()()
+	positions := map[string]int{}
+	var stats []CommentUserStat
+	for _, comment := range commentIndex.comments {
+		created := time.Unix(int64(comment.Created), 0)
+		i, ok := positions[comment.FromUser.Id]
+		if !ok {
+			i = len(stats)
+			positions[comment.FromUser.Id] = i
+			stats = append(stats, CommentUserStat{User: comment.FromUser, First: created})
+		}
+		stats[i].Count++
+		stats[i].Last = created
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Count > stats[j].Count
+	})
+	return stats
+}
+
+// TopWords returns the n most used words, none when n is not positive. Words are runs of latin letters and digits, kanji or
+// katakana of at least two characters; hiragana is left out as it is mostly grammar.
+func (commentIndex *CommentIndex) TopWords(n int) []CommentWordCount {
+	commentIndex.mu.RLock()
+	counts := map[string]int{}
+	for _, comment := range commentIndex.comments {
+		for _, word := range commentWords(comment.Message) {
+			counts[word]++
+		}
+	}
+	commentIndex.mu.RUnlock()
+	words := make([]CommentWordCount, 0, len(counts))
+	for word, count := range counts {
+		words = append(words, CommentWordCount{Word: word, Count: count})
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
+	})
+	return words[:min(max(n, 0), len(words))]
+}
+
+// PerMinute returns the number of comments per minute from the first comment to the last one,
+// including the minutes without comments.
+func (commentIndex *CommentIndex) PerMinute() []CommentMinuteCount {
+	defer commentIndex.lockBuilt()()
+	if len(commentIndex.comments) == 0 {
+		return nil
+	}
+	first := time.Unix(int64(commentIndex.comments[0].Created), 0).Truncate(time.Minute)
+	last := time.Unix(int64(commentIndex.comments[len(commentIndex.comments)-1].Created), 0).Truncate(time.Minute)
+	histogram := make([]CommentMinuteCount, int(last.Sub(first)/time.Minute)+1)
+	for i := range histogram {
+		histogram[i].Minute = first.Add(time.Duration(i) * time.Minute)
+	}
+	for _, comment := range commentIndex.comments {
+		histogram[int(time.Unix(int64(comment.Created), 0).Sub(first)/time.Minute)].Count++
+	}
+	return histogram
+}
+
+// FirstTimeCommenters returns the first comment of every user not in knownUserIds, oldest first.
+// Pass the users of previous movies to find newcomers.
+func (commentIndex *CommentIndex) FirstTimeCommenters(knownUserIds []string) []Comment {
+	defer commentIndex.lockBuilt()()
+	seen := map[string]struct{}{}
+	for _, userId := range knownUserIds {
+		seen[userId] = struct{}{}
+	}
+	var firsts []Comment
+	for _, comment := range commentIndex.comments {
+		if _, ok := seen[comment.FromUser.Id]; ok {
+			continue
+		}
+		seen[comment.FromUser.Id] = struct{}{}
+		firsts = append(firsts, comment)
+	}
+	return firsts
+}
+
+// lockBuilt locks the index with the postings built and returns the matching unlock function.
+// Once built, readers share the lock; otherwise the write lock is kept for the whole read so an
+// Add cannot slip in between building and reading.
+func (commentIndex *CommentIndex) lockBuilt() func() {
+	commentIndex.mu.RLock()
+	if commentIndex.sorted {
+		return commentIndex.mu.RUnlock
+	}
+	commentIndex.mu.RUnlock()
+	commentIndex.mu.Lock()
+	commentIndex.build()
+	return commentIndex.mu.Unlock
+}
+
+// build sorts the comments and rebuilds the postings. The write lock must be held.
+func (commentIndex *CommentIndex) build() {
+	if commentIndex.sorted {
+		return
+	}
+	commentIndex.comments = chronologicalComments(commentIndex.comments)
+	commentIndex.postings = map[string][]int{}
+	for i, comment := range commentIndex.comments {
+		for _, gram := range commentGrams(normalizeCommentText(comment.Message)) {
+			postings := commentIndex.postings[gram]
+			if len(postings) == 0 || postings[len(postings)-1] != i {
+				commentIndex.postings[gram] = append(postings, i)
+			}
+		}
+	}
+	commentIndex.sorted = true
+}
+
+// candidates returns the positions of the comments containing every gram of text.
+func (commentIndex *CommentIndex) candidates(text string) []int {
+	grams := commentGrams(text)
+	if len(grams) == 0 {
+		all := make([]int, len(commentIndex.comments))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	// unigrams are implied by the bigrams of the same word
+	bigrams := slices.DeleteFunc(slices.Clone(grams), func(gram string) bool {
+		return len([]rune(gram)) == 1
+	})
+	if len(bigrams) > 0 {
+		grams = bigrams
+	}
+	candidates := commentIndex.postings[grams[0]]
+	for _, gram := range grams[1:] {
+		postings := commentIndex.postings[gram]
+		var both []int
+		for i, j := 0, 0; i < len(candidates) && j < len(postings); {
+			switch {
+			case candidates[i] < postings[j]:
+				i++
+			case candidates[i] > postings[j]:
+				j++
+			default:
+				both = append(both, candidates[i])
+				i++
+				j++
+			}
+		}
+		candidates = both
+	}
+	return candidates
+}
+
+// commentGrams returns the unigrams and bigrams of the words in text.
+func commentGrams(text string) []string {
+	var grams []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != 'ー'
+	}) {
+		runes := []rune(word)
+		for i := range runes {
+			grams = append(grams, string(runes[i]))
+			if i+1 < len(runes) {
+				grams = append(grams, string(runes[i:i+2]))
+			}
+		}
+	}
+	return grams
+}
+
+type commentScript int
+
+const (
+	commentScriptNone commentScript = iota
+	commentScriptLatin
+	commentScriptHan
+	commentScriptHiragana
+	commentScriptKatakana
+)
+
+func scriptOf(r rune) commentScript {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return commentScriptHan
+	case unicode.Is(unicode.Hiragana, r):
+		return commentScriptHiragana
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		return commentScriptKatakana
+	case unicode.IsLetter(r) || unicode.IsNumber(r):
+		return commentScriptLatin
+	default:
+		return commentScriptNone
+	}
+}
+
+// commentWords splits message at script changes and keeps the words counted by TopWords.
+func commentWords(message string) []string {
+	var words []string
+	var word []rune
+	script := commentScriptNone
+	flush := func() {
+		if len(word) >= 2 && script != commentScriptHiragana && script != commentScriptNone {
+			words = append(words, string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range normalizeCommentText(message) {
+		if s := scriptOf(r); s != script {
+			flush()
+			script = s
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
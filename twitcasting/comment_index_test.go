@@ -0,0 +1,94 @@
+package twitcasting_test
+
+import (
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var indexComments = &twitcasting.CommentListContainer{
+	MovieId:  "100",
+	AllCount: 6,
+	Comments: []twitcasting.Comment{
+		{Id: "6", Message: "ゲーム楽しい！", FromUser: twitcasting.User{Id: "3", ScreenId: "carol"}, Created: 1700000190},
+		{Id: "5", Message: "Hello again", FromUser: twitcasting.User{Id: "1", ScreenId: "alice"}, Created: 1700000130},
+		{Id: "4", Message: "ゲームの配信ですか", FromUser: twitcasting.User{Id: "2", ScreenId: "bob"}, Created: 1700000070},
+		{Id: "3", Message: "楽しみにしてました", FromUser: twitcasting.User{Id: "1", ScreenId: "alice"}, Created: 1700000010},
+		{Id: "2", Message: "ＨＥＬＬＯ world", FromUser: twitcasting.User{Id: "2", ScreenId: "bob"}, Created: 1700000005},
+		{Id: "1", Message: "こんにちは", FromUser: twitcasting.User{Id: "1", ScreenId: "alice"}, Created: 1700000000},
+	},
+}
+
+func TestCommentIndexSearch(t *testing.T) {
+	index := &twitcasting.CommentIndex{}
+	index.AddContainer(indexComments)
+	index.AddContainer(indexComments)
+	assert.Equal(t, 6, index.Len())
+
+	assert.Equal(t, []string{"4", "6"}, commentIds(index.Search(twitcasting.CommentQuery{Text: "ゲーム"})))
+	assert.Equal(t, []string{"3", "6"}, commentIds(index.Search(twitcasting.CommentQuery{Text: "楽"})))
+	assert.Equal(t, []string{"2", "5"}, commentIds(index.Search(twitcasting.CommentQuery{Text: "hello"})))
+	assert.Equal(t, []string{"5"}, commentIds(index.Search(twitcasting.CommentQuery{Text: "hello", UserIds: []string{"1"}})))
+	assert.Empty(t, index.Search(twitcasting.CommentQuery{Text: "ムゲ"}))
+	assert.Equal(t, []string{"5"}, commentIds(index.Search(twitcasting.CommentQuery{Text: "o a"})))
+	assert.Equal(t, []string{"3", "4"}, commentIds(index.Search(twitcasting.CommentQuery{
+		From: time.Unix(1700000010, 0),
+		To:   time.Unix(1700000130, 0),
+	})))
+	assert.Equal(t, []string{"1"}, commentIds(index.Search(twitcasting.CommentQuery{Limit: 1})))
+}
+
+func TestCommentIndexStats(t *testing.T) {
+	index := &twitcasting.CommentIndex{}
+	index.AddContainer(indexComments)
+
+	users := index.UserStats()
+	assert.Equal(t, 3, len(users))
+	assert.Equal(t, "alice", users[0].User.ScreenId)
+	assert.Equal(t, 3, users[0].Count)
+	assert.Equal(t, time.Unix(1700000000, 0), users[0].First)
+	assert.Equal(t, time.Unix(1700000130, 0), users[0].Last)
+
+	assert.Equal(t, []twitcasting.CommentWordCount{{Word: "hello", Count: 2}, {Word: "ゲーム", Count: 2}, {Word: "again", Count: 1}}, index.TopWords(3))
+
+	assert.Empty(t, index.TopWords(-1))
+
+	histogram := index.PerMinute()
+	assert.Equal(t, 4, len(histogram))
+	var counts []int
+	for _, minute := range histogram {
+		counts = append(counts, minute.Count)
+	}
+	assert.Equal(t, []int{3, 1, 1, 1}, counts)
+	assert.Equal(t, time.Unix(1700000000, 0).Truncate(time.Minute), histogram[0].Minute)
+
+	assert.Equal(t, []string{"1", "2", "6"}, commentIds(index.FirstTimeCommenters(nil)))
+	assert.Equal(t, []string{"6"}, commentIds(index.FirstTimeCommenters([]string{"1", "2"})))
+}
+
+func TestCommentIndexConcurrentAdd(t *testing.T) {
+	index := &twitcasting.CommentIndex{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// comments arrive newest first, so every Add leaves the index unsorted
+		for i := 200; i > 0; i-- {
+			index.Add(twitcasting.Comment{Id: strconv.Itoa(i), Message: "hello", FromUser: twitcasting.User{Id: strconv.Itoa(i % 7)}, Created: 1700000000 + i*30})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			index.PerMinute()
+			index.Search(twitcasting.CommentQuery{Text: "hello"})
+			index.UserStats()
+			index.FirstTimeCommenters(nil)
+		}
+	}()
+	wg.Wait()
+	assert.Equal(t, 200, len(index.Search(twitcasting.CommentQuery{Text: "hello"})))
+}
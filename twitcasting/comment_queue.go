@@ -0,0 +1,285 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxCommentLength is the length limit of PostComment in characters.
+const MaxCommentLength = 140
+
+// CommentSns is the sns parameter of PostComment.
+type CommentSns string
+
+const (
+	CommentSnsNone   CommentSns = "none"
+	CommentSnsReply  CommentSns = "reply"
+	CommentSnsNormal CommentSns = "normal"
+)
+
+// ValidateComment checks the length limit of PostComment.
+func ValidateComment(message string) error {
+	length := utf8.RuneCountInString(message)
+	if length < 1 || length > MaxCommentLength {
+		return fmt.Errorf("comment must be 1 to %v characters: %q", MaxCommentLength, message)
+	}
+	return nil
+}
+
+func ValidateCommentSns(sns CommentSns) error {
+	switch sns {
+	case CommentSnsNone, CommentSnsReply, CommentSnsNormal:
+		return nil
+	default:
+		return fmt.Errorf("sns must be none, reply or normal: %q", sns)
+	}
+}
+
+// CommentOverflow decides what CommentQueue does with messages longer than MaxCommentLength.
+type CommentOverflow string
+
+const (
+	CommentSplit    CommentOverflow = "split"
+	CommentTruncate CommentOverflow = "truncate"
+	CommentReject   CommentOverflow = "reject"
+)
+
+// CommentDelivery is the result of one posted comment. Part counts from 1 when a message was split.
+type CommentDelivery struct {
+	MovieId   string
+	Message   string
+	Sns       CommentSns
+	Part      int
+	Parts     int
+	Duplicate bool
+	Comment   *Comment
+	// ErrorResponse is set when the API rejected the comment.
+	ErrorResponse *ErrorResponse
+	Err           error
+	Time          time.Time
+}
+
+// CommentQueue posts comments in order with at least Interval between two comments to the same movie.
+// Each movie is served by its own goroutine while it has pending comments.
+type CommentQueue struct {
+	CommentService *CommentService
+	Interval       time.Duration
+	// DedupeWindow drops a message enqueued again for the same movie within the window.
+	// A message that fails to be posted can be enqueued again right away.
+	DedupeWindow time.Duration
+	Overflow     CommentOverflow
+
+	mu       sync.Mutex
+	pending  map[string][]commentQueueItem
+	serving  map[string]bool
+	lastSent map[string]time.Time
+	recent   map[string]time.Time
+}
+
+type commentQueueItem struct {
+	ctx      context.Context
+	delivery CommentDelivery
+	results  chan CommentDelivery
+	// dedupeKey and enqueued identify the dedupe record of the whole message.
+	dedupeKey string
+	enqueued  time.Time
+}
+
+func CreateCommentQueue(commentService *CommentService) *CommentQueue {
+	return &CommentQueue{
+		CommentService: commentService,
+		Interval:       2 * time.Second,
+		DedupeWindow:   30 * time.Second,
+		Overflow:       CommentSplit,
+	}
+}
+
+// Enqueue validates message and schedules it. The returned channel receives one CommentDelivery
+// per posted part and is closed afterwards. Comments still pending when ctx is done fail with ctx.Err().
+func (commentQueue *CommentQueue) Enqueue(ctx context.Context, movieId string, message string, sns CommentSns) (<-chan CommentDelivery, error) {
+	if err := ValidateCommentSns(sns); err != nil {
+		return nil, err
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, errors.New("comment is empty")
+	}
+	var parts []string
+	switch commentQueue.Overflow {
+	case CommentReject:
+		if err := ValidateComment(message); err != nil {
+			return nil, err
+		}
+		parts = []string{message}
+	case CommentTruncate:
+		parts = []string{truncateComment(message, MaxCommentLength)}
+	default:
+		parts = splitComment(message, MaxCommentLength)
+	}
+
+	commentQueue.mu.Lock()
+	defer commentQueue.mu.Unlock()
+	now := time.Now()
+	results := make(chan CommentDelivery, len(parts))
+	if commentQueue.duplicate(movieId, message, now) {
+		results <- CommentDelivery{MovieId: movieId, Message: message, Sns: sns, Part: 1, Parts: 1, Duplicate: true, Time: now}
+		close(results)
+		return results, nil
+	}
+	if commentQueue.pending == nil {
+		commentQueue.pending = map[string][]commentQueueItem{}
+		commentQueue.serving = map[string]bool{}
+		commentQueue.lastSent = map[string]time.Time{}
+	}
+	for i, part := range parts {
+		commentQueue.pending[movieId] = append(commentQueue.pending[movieId], commentQueueItem{
+			ctx:       ctx,
+			delivery:  CommentDelivery{MovieId: movieId, Message: part, Sns: sns, Part: i + 1, Parts: len(parts)},
+			results:   results,
+			dedupeKey: commentDedupeKey(movieId, message),
+			enqueued:  now,
+		})
+	}
+	if !commentQueue.serving[movieId] {
+		commentQueue.serving[movieId] = true
+		go commentQueue.serve(movieId)
+	}
+	return results, nil
+}
+
+// Send enqueues message and waits until every part is delivered.
+// The error is the first failure of the deliveries.
+func (commentQueue *CommentQueue) Send(ctx context.Context, movieId string, message string, sns CommentSns) ([]CommentDelivery, error) {
+	results, err := commentQueue.Enqueue(ctx, movieId, message, sns)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []CommentDelivery
+	for delivery := range results {
+		deliveries = append(deliveries, delivery)
+		if delivery.Err != nil && err == nil {
+			err = delivery.Err
+		}
+	}
+	return deliveries, err
+}
+
+// Pending returns the number of comments waiting for movieId.
+func (commentQueue *CommentQueue) Pending(movieId string) int {
+	commentQueue.mu.Lock()
+	defer commentQueue.mu.Unlock()
+	return len(commentQueue.pending[movieId])
+}
+
+// duplicate reports whether message was enqueued for movieId within DedupeWindow and records it otherwise.
+func (commentQueue *CommentQueue) duplicate(movieId string, message string, now time.Time) bool {
+	if commentQueue.DedupeWindow <= 0 {
+		return false
+	}
+	if commentQueue.recent == nil {
+		commentQueue.recent = map[string]time.Time{}
+	}
+	for key, enqueued := range commentQueue.recent {
+		if now.Sub(enqueued) >= commentQueue.DedupeWindow {
+			delete(commentQueue.recent, key)
+		}
+	}
+	key := commentDedupeKey(movieId, message)
+	if _, ok := commentQueue.recent[key]; ok {
+		return true
+	}
+	commentQueue.recent[key] = now
+	return false
+}
+
+// forget drops the dedupe record of the message of item so that it can be enqueued again.
+func (commentQueue *CommentQueue) forget(item commentQueueItem) {
+	if recorded, ok := commentQueue.recent[item.dedupeKey]; ok && recorded.Equal(item.enqueued) {
+		delete(commentQueue.recent, item.dedupeKey)
+	}
+}
+
+func commentDedupeKey(movieId string, message string) string {
+	return movieId + "\x00" + message
+}
+
+func (commentQueue *CommentQueue) serve(movieId string) {
+	logger := *commentQueue.CommentService.Logger
+	for {
+		commentQueue.mu.Lock()
+		if len(commentQueue.pending[movieId]) == 0 {
+			delete(commentQueue.pending, movieId)
+			delete(commentQueue.serving, movieId)
+			commentQueue.mu.Unlock()
+			return
+		}
+		item := commentQueue.pending[movieId][0]
+		wait := time.Until(commentQueue.lastSent[movieId].Add(commentQueue.Interval))
+		commentQueue.mu.Unlock()
+
+		delivery := item.delivery
+		delivery.Err = sleepContext(item.ctx, wait)
+		if delivery.Err == nil {
+			var container *CommentContainer
+			container, delivery.ErrorResponse, delivery.Err = commentQueue.CommentService.PostComment(movieId, delivery.Message, string(delivery.Sns))
+			if container != nil {
+				delivery.Comment = &container.Comment
+			}
+			if delivery.Err != nil {
+				logger.Warn("post comment failed for CommentQueue", movieId, delivery.Err)
+			}
+		}
+		delivery.Time = time.Now()
+
+		commentQueue.mu.Lock()
+		if delivery.Comment != nil || delivery.ErrorResponse != nil {
+			commentQueue.lastSent[movieId] = delivery.Time
+		}
+		if delivery.Err != nil {
+			commentQueue.forget(item)
+		}
+		commentQueue.pending[movieId] = commentQueue.pending[movieId][1:]
+		commentQueue.mu.Unlock()
+		item.results <- delivery
+		if delivery.Part == delivery.Parts {
+			close(item.results)
+		}
+	}
+}
+
+// splitComment splits message into parts of at most limit characters, preferring to break
+// after white space or Japanese punctuation in the second half of a part.
+func splitComment(message string, limit int) []string {
+	var parts []string
+	runes := []rune(message)
+	for len(runes) > limit {
+		cut := limit
+		for i := limit; i > limit/2; i-- {
+			if strings.ContainsRune(" \n\t　、。！？!?", runes[i-1]) {
+				cut = i
+				break
+			}
+		}
+		if part := strings.TrimSpace(string(runes[:cut])); part != "" {
+			parts = append(parts, part)
+		}
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+func truncateComment(message string, limit int) string {
+	runes := []rune(message)
+	if len(runes) <= limit {
+		return message
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,137 @@
+package twitcasting_test
+
+import (
+	"context"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestValidateComment(t *testing.T) {
+	assert.Nil(t, twitcasting.ValidateComment(strings.Repeat("あ", 140)))
+	assert.NotNil(t, twitcasting.ValidateComment(strings.Repeat("あ", 141)))
+	assert.NotNil(t, twitcasting.ValidateComment(""))
+	assert.Nil(t, twitcasting.ValidateCommentSns(twitcasting.CommentSnsReply))
+	assert.NotNil(t, twitcasting.ValidateCommentSns("twitter"))
+}
+
+func TestCommentQueueSend(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 20 * time.Millisecond
+
+	_, err := queue.Send(context.Background(), "100", "hello", "twitter")
+	assert.NotNil(t, err)
+
+	long := strings.Repeat("あ", 100) + "。" + strings.Repeat("い", 100)
+	started := time.Now()
+	deliveries, err := queue.Send(context.Background(), "100", long, twitcasting.CommentSnsNormal)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+	assert.Equal(t, 2, len(deliveries))
+	assert.Equal(t, strings.Repeat("あ", 100)+"。", deliveries[0].Message)
+	assert.Equal(t, 2, deliveries[1].Part)
+	assert.Equal(t, strings.Repeat("い", 100), deliveries[1].Comment.Message)
+	assert.Equal(t, twitcasting.CommentSnsNormal, twitcasting.CommentSns(fake.posted[0].Sns))
+
+	deliveries, err = queue.Send(context.Background(), "100", long, twitcasting.CommentSnsNormal)
+	assert.Nil(t, err)
+	assert.True(t, deliveries[0].Duplicate)
+	assert.Equal(t, 2, len(fake.messages()))
+}
+
+func TestCommentQueueOverflow(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 0
+
+	queue.Overflow = twitcasting.CommentReject
+	_, err := queue.Send(context.Background(), "100", strings.Repeat("a", 141), twitcasting.CommentSnsNone)
+	assert.NotNil(t, err)
+
+	queue.Overflow = twitcasting.CommentTruncate
+	deliveries, err := queue.Send(context.Background(), "100", strings.Repeat("a", 141), twitcasting.CommentSnsNone)
+	assert.Nil(t, err)
+	assert.Equal(t, 140, utf8.RuneCountInString(deliveries[0].Message))
+	assert.True(t, strings.HasSuffix(deliveries[0].Message, "…"))
+}
+
+func TestCommentQueueRetryAfterFailure(t *testing.T) {
+	fake := &fakeChatServer{}
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"code":500,"message":"Internal Server Error"}}`))
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 0
+
+	_, err := queue.Send(context.Background(), "100", "hello", twitcasting.CommentSnsNone)
+	assert.NotNil(t, err)
+	deliveries, err := queue.Send(context.Background(), "100", "hello", twitcasting.CommentSnsNone)
+	assert.Nil(t, err)
+	assert.False(t, deliveries[0].Duplicate)
+	assert.Equal(t, []string{"hello"}, fake.messages())
+
+	deliveries, err = queue.Send(context.Background(), "100", "hello", twitcasting.CommentSnsNone)
+	assert.Nil(t, err)
+	assert.True(t, deliveries[0].Duplicate)
+}
+
+func TestCommentQueueCanceled(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = time.Hour
+
+	_, err := queue.Send(context.Background(), "100", "first", twitcasting.CommentSnsNone)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := queue.Enqueue(ctx, "100", "second", twitcasting.CommentSnsNone)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, queue.Pending("100"))
+	cancel()
+	delivery := <-results
+	assert.Equal(t, context.Canceled, delivery.Err)
+	assert.Equal(t, []string{"first"}, fake.messages())
+}
+
+func TestChatBotQueue(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	bot := twitcasting.CreateChatBot(locator.Comment, locator.Supporter, "100", "owner")
+	bot.Queue = twitcasting.CreateCommentQueue(locator.Comment)
+	bot.Sns = twitcasting.CommentSnsReply
+	assert.Nil(t, bot.Register(twitcasting.ChatBotCommand{Name: "ping", Reply: "pong"}))
+
+	reply, err := bot.HandleComment(context.Background(), chatComment("viewer", "Alice", "!ping"))
+	assert.Nil(t, err)
+	assert.Equal(t, "pong", reply)
+	// the queue drops the same reply within its dedupe window
+	_, err = bot.HandleComment(context.Background(), chatComment("viewer", "Alice", "!ping"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pong"}, fake.messages())
+	assert.Equal(t, "reply", fake.posted[0].Sns)
+}
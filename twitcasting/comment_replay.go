@@ -0,0 +1,151 @@
+package twitcasting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CommentReplay emits the comments of a recorded movie at the pace they were posted, relative to
+// Movie.Created. The clock starts with the first Run. The replay can be paused, sought and sped
+// up while Run is in progress.
+type CommentReplay struct {
+	mu       sync.Mutex
+	comments []Comment
+	offsets  []time.Duration
+	next     int
+	speed    float64
+	position time.Duration
+	anchor   time.Time
+	paused   bool
+	started  bool
+	changed  chan struct{}
+}
+
+func CreateCommentReplay(movie Movie, comments []Comment) *CommentReplay {
+	commentReplay := &CommentReplay{
+		comments: chronologicalComments(comments),
+		speed:    1,
+		changed:  make(chan struct{}),
+	}
+	for _, comment := range commentReplay.comments {
+		commentReplay.offsets = append(commentReplay.offsets, commentOffset(movie, comment))
+	}
+	return commentReplay
+}
+
+// Run sends the comments to out as their offsets are reached. It returns nil after the last
+// comment, or ctx.Err() when ctx is done first.
+func (commentReplay *CommentReplay) Run(ctx context.Context, out chan<- Comment) error {
+	commentReplay.mu.Lock()
+	if !commentReplay.started {
+		commentReplay.started = true
+		commentReplay.anchor = time.Now()
+	}
+	commentReplay.mu.Unlock()
+	for {
+		commentReplay.mu.Lock()
+		if commentReplay.next >= len(commentReplay.comments) {
+			commentReplay.mu.Unlock()
+			return nil
+		}
+		changed := commentReplay.changed
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if !commentReplay.paused {
+			due := commentReplay.offsets[commentReplay.next] - commentReplay.positionLocked()
+			if due <= 0 {
+				comment := commentReplay.comments[commentReplay.next]
+				commentReplay.next++
+				commentReplay.mu.Unlock()
+				select {
+				case out <- comment:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+			timer = time.NewTimer(time.Duration(float64(due) / commentReplay.speed))
+			wait = timer.C
+		}
+		commentReplay.mu.Unlock()
+		select {
+		case <-wait:
+		case <-changed:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// SetSpeed changes the playback rate, 2 plays twice as fast. Speeds of 0 or below are ignored.
+func (commentReplay *CommentReplay) SetSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+	commentReplay.mu.Lock()
+	defer commentReplay.mu.Unlock()
+	commentReplay.rebase()
+	commentReplay.speed = speed
+	commentReplay.notify()
+}
+
+// Seek moves the replay to offset. Comments before it are skipped, the ones after it are emitted again.
+func (commentReplay *CommentReplay) Seek(offset time.Duration) {
+	commentReplay.mu.Lock()
+	defer commentReplay.mu.Unlock()
+	commentReplay.position = max(offset, 0)
+	commentReplay.anchor = time.Now()
+	commentReplay.next = sort.Search(len(commentReplay.offsets), func(i int) bool {
+		return commentReplay.offsets[i] >= commentReplay.position
+	})
+	commentReplay.notify()
+}
+
+func (commentReplay *CommentReplay) Pause() {
+	commentReplay.mu.Lock()
+	defer commentReplay.mu.Unlock()
+	commentReplay.rebase()
+	commentReplay.paused = true
+	commentReplay.notify()
+}
+
+func (commentReplay *CommentReplay) Resume() {
+	commentReplay.mu.Lock()
+	defer commentReplay.mu.Unlock()
+	commentReplay.rebase()
+	commentReplay.paused = false
+	commentReplay.notify()
+}
+
+// Position returns the current offset from Movie.Created.
+func (commentReplay *CommentReplay) Position() time.Duration {
+	commentReplay.mu.Lock()
+	defer commentReplay.mu.Unlock()
+	return commentReplay.positionLocked()
+}
+
+func (commentReplay *CommentReplay) positionLocked() time.Duration {
+	if commentReplay.paused || !commentReplay.started {
+		return commentReplay.position
+	}
+	return commentReplay.position + time.Duration(float64(time.Since(commentReplay.anchor))*commentReplay.speed)
+}
+
+// rebase stores the current position so speed and pause changes apply from now on.
+func (commentReplay *CommentReplay) rebase() {
+	commentReplay.position = commentReplay.positionLocked()
+	commentReplay.anchor = time.Now()
+}
+
+// notify wakes Run up to recompute its wait.
+func (commentReplay *CommentReplay) notify() {
+	close(commentReplay.changed)
+	commentReplay.changed = make(chan struct{})
+}
@@ -0,0 +1,69 @@
+package twitcasting_test
+
+import (
+	"context"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var replayMovie = twitcasting.Movie{Id: "100", Created: 1700000000}
+
+var replayComments = []twitcasting.Comment{
+	{Id: "3", Message: "third", Created: 1700000060},
+	{Id: "1", Message: "first", Created: 1700000000},
+	{Id: "2", Message: "second", Created: 1700000002},
+}
+
+func TestCommentReplayRun(t *testing.T) {
+	replay := twitcasting.CreateCommentReplay(replayMovie, replayComments)
+	replay.SetSpeed(1000)
+	out := make(chan twitcasting.Comment, 3)
+	started := time.Now()
+	assert.Nil(t, replay.Run(context.Background(), out))
+	elapsed := time.Since(started)
+	assert.GreaterOrEqual(t, elapsed, 60*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+	close(out)
+	var ids []string
+	for comment := range out {
+		ids = append(ids, comment.Id)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
+
+func TestCommentReplayControls(t *testing.T) {
+	replay := twitcasting.CreateCommentReplay(replayMovie, replayComments)
+	replay.Pause()
+	out := make(chan twitcasting.Comment)
+	done := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- replay.Run(ctx, out)
+	}()
+
+	// the comment at offset 0 waits while paused
+	select {
+	case <-out:
+		t.Fatal("comment emitted while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, time.Duration(0), replay.Position())
+
+	replay.Seek(2 * time.Second)
+	replay.Resume()
+	assert.Equal(t, "2", (<-out).Id)
+
+	replay.SetSpeed(10000)
+	assert.Equal(t, "3", (<-out).Id)
+	assert.Nil(t, <-done)
+
+	replay.Seek(0)
+	go func() {
+		done <- replay.Run(ctx, out)
+	}()
+	assert.Equal(t, "1", (<-out).Id)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
@@ -0,0 +1,218 @@
+package twitcasting
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// commentsPageLimit is the maximum limit accepted by the get-comments endpoint.
+const commentsPageLimit = 50
+
+// CommentStream polls GetCommentsBySliceId and delivers every new comment once, oldest first.
+// The poll interval shrinks to MinInterval while comments arrive and doubles up to MaxInterval when idle.
+// Intervals that are not positive default to 2 and 15 seconds.
+type CommentStream struct {
+	CommentService *CommentService
+	MovieId        string
+	MinInterval    time.Duration
+	MaxInterval    time.Duration
+	// CursorStore is optional. The newest delivered comment id is saved under "comments:<movie id>"
+	// after the comment has been handed over, so a restart resumes with the first undelivered comment.
+	CursorStore CursorStore
+	// IncludeExisting delivers the comments posted before the first poll when no cursor is stored.
+	IncludeExisting bool
+	UseBearerToken  bool
+
+	cursor string
+}
+
+// defaultCommentStreamMinInterval and defaultCommentStreamMaxInterval are used by Run when
+// MinInterval and MaxInterval are not positive.
+const (
+	defaultCommentStreamMinInterval = 2 * time.Second
+	defaultCommentStreamMaxInterval = 15 * time.Second
+)
+
+func CreateCommentStream(commentService *CommentService, movieId string) *CommentStream {
+	return &CommentStream{
+		CommentService: commentService,
+		MovieId:        movieId,
+		MinInterval:    defaultCommentStreamMinInterval,
+		MaxInterval:    defaultCommentStreamMaxInterval,
+	}
+}
+
+// Cursor returns the id of the newest comment delivered so far.
+func (commentStream *CommentStream) Cursor() string {
+	return commentStream.cursor
+}
+
+// Run sends new comments to out until ctx is done and returns ctx.Err().
+func (commentStream *CommentStream) Run(ctx context.Context, out chan<- Comment) error {
+	return commentStream.run(ctx, func(comment Comment) bool {
+		select {
+		case out <- comment:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// RunFunc calls fn for each new comment until ctx is done and returns ctx.Err().
+func (commentStream *CommentStream) RunFunc(ctx context.Context, fn func(Comment)) error {
+	return commentStream.run(ctx, func(comment Comment) bool {
+		fn(comment)
+		return true
+	})
+}
+
+// run advances the cursor past each comment only once deliver has accepted it.
+func (commentStream *CommentStream) run(ctx context.Context, deliver func(Comment) bool) error {
+	logger := *commentStream.CommentService.Logger
+	minInterval := commentStream.MinInterval
+	if minInterval <= 0 {
+		minInterval = defaultCommentStreamMinInterval
+	}
+	maxInterval := commentStream.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultCommentStreamMaxInterval
+	}
+	maxInterval = max(maxInterval, minInterval)
+	interval := minInterval
+	for {
+		comments, err := commentStream.fetch()
+		if err != nil {
+			logger.Warn("poll failed for CommentStream", err)
+			interval = maxInterval
+		} else if len(comments) > 0 {
+			interval = minInterval
+		} else {
+			interval = min(interval*2, maxInterval)
+		}
+		for _, comment := range comments {
+			if ctx.Err() != nil || !deliver(comment) {
+				return ctx.Err()
+			}
+			if err = commentStream.advance(comment.Id); err != nil {
+				logger.Warn("save cursor failed for CommentStream", comment.Id, err)
+			}
+		}
+		if err = sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll fetches the comments newer than the cursor, oldest first, and advances the cursor.
+// When a page is full, the comments in between are fetched with offset paging so bursts are not lost.
+func (commentStream *CommentStream) Poll() ([]Comment, error) {
+	comments, err := commentStream.fetch()
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+	if err = commentStream.advance(comments[len(comments)-1].Id); err != nil {
+		return comments, err
+	}
+	return comments, nil
+}
+
+// fetch returns the comments newer than the cursor, oldest first, without advancing it.
+func (commentStream *CommentStream) fetch() ([]Comment, error) {
+	if commentStream.cursor == "" {
+		if err := commentStream.initCursor(); err != nil {
+			return nil, err
+		}
+	}
+	page, _, err := commentStream.CommentService.GetCommentsBySliceId(commentStream.MovieId, commentsPageLimit, commentStream.cursor, commentStream.UseBearerToken)
+	if err != nil {
+		return nil, err
+	}
+	comments := page.Comments
+	if len(comments) >= commentsPageLimit {
+		comments, err = commentStream.fetchSince(commentStream.cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commentsNewerThan(comments, commentStream.cursor), nil
+}
+
+// advance moves the cursor to commentId and saves it to CursorStore.
+func (commentStream *CommentStream) advance(commentId string) error {
+	commentStream.cursor = commentId
+	if commentStream.CursorStore == nil {
+		return nil
+	}
+	return commentStream.CursorStore.SaveCursor(commentStream.cursorKey(), commentId)
+}
+
+func (commentStream *CommentStream) cursorKey() string {
+	return "comments:" + commentStream.MovieId
+}
+
+func (commentStream *CommentStream) initCursor() error {
+	if commentStream.CursorStore != nil {
+		cursor, err := commentStream.CursorStore.LoadCursor(commentStream.cursorKey())
+		if err != nil {
+			return err
+		}
+		if cursor != "" {
+			commentStream.cursor = cursor
+			return nil
+		}
+	}
+	commentStream.cursor = "0"
+	if commentStream.IncludeExisting {
+		return nil
+	}
+	latest, _, err := commentStream.CommentService.GetComments(commentStream.MovieId, 1, 0, commentStream.UseBearerToken)
+	if err != nil {
+		commentStream.cursor = ""
+		return err
+	}
+	for _, comment := range latest.Comments {
+		if compareIds(comment.Id, commentStream.cursor) > 0 {
+			commentStream.cursor = comment.Id
+		}
+	}
+	return nil
+}
+
+// fetchSince pages through GetComments from the newest comment back to sliceId.
+func (commentStream *CommentStream) fetchSince(sliceId string) ([]Comment, error) {
+	var comments []Comment
+	for offset := 0; ; offset += commentsPageLimit {
+		page, _, err := commentStream.CommentService.GetComments(commentStream.MovieId, commentsPageLimit, offset, commentStream.UseBearerToken)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page.Comments...)
+		if len(page.Comments) < commentsPageLimit {
+			return comments, nil
+		}
+		for _, comment := range page.Comments {
+			if compareIds(comment.Id, sliceId) <= 0 {
+				return comments, nil
+			}
+		}
+	}
+}
+
+// commentsNewerThan returns the unique comments with an id greater than cursor, oldest first.
+func commentsNewerThan(comments []Comment, cursor string) []Comment {
+	seen := map[string]struct{}{}
+	var newer []Comment
+	for _, comment := range comments {
+		if _, ok := seen[comment.Id]; ok || compareIds(comment.Id, cursor) <= 0 {
+			continue
+		}
+		seen[comment.Id] = struct{}{}
+		newer = append(newer, comment)
+	}
+	sort.Slice(newer, func(i, j int) bool {
+		return compareIds(newer[i].Id, newer[j].Id) < 0
+	})
+	return newer
+}
@@ -0,0 +1,223 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCommentsServer struct {
+	mu       sync.Mutex
+	comments []twitcasting.Comment // oldest first
+	nextId   int
+	requests int
+	// maxOffset rejects larger offsets when it is not 0
+	maxOffset int
+}
+
+func (fake *fakeCommentsServer) post(count int) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for i := 0; i < count; i++ {
+		fake.nextId++
+		fake.comments = append(fake.comments, twitcasting.Comment{
+			Id:       strconv.Itoa(fake.nextId),
+			Message:  "message " + strconv.Itoa(fake.nextId),
+			FromUser: twitcasting.User{Id: "user" + strconv.Itoa(fake.nextId%3)},
+			Created:  1700000000 + fake.nextId,
+		})
+	}
+}
+
+func (fake *fakeCommentsServer) remove(id string) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for i, comment := range fake.comments {
+		if comment.Id == id {
+			fake.comments = append(fake.comments[:i], fake.comments[i+1:]...)
+			return
+		}
+	}
+}
+
+// ServeHTTP answers newest first. With slice_id only the newest comments above it are returned.
+func (fake *fakeCommentsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests++
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	var newestFirst []twitcasting.Comment
+	for i := len(fake.comments) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, fake.comments[i])
+	}
+	if query.Has("slice_id") {
+		sliceId, _ := strconv.Atoi(query.Get("slice_id"))
+		var newer []twitcasting.Comment
+		for _, comment := range newestFirst {
+			if id, _ := strconv.Atoi(comment.Id); id > sliceId {
+				newer = append(newer, comment)
+			}
+		}
+		newestFirst = newer
+	} else {
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		if fake.maxOffset > 0 && offset > fake.maxOffset {
+			w.WriteHeader(http.StatusBadRequest)
+			body, _ := json.Marshal(twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 1001, Message: "Validation error"}})
+			_, _ = w.Write(body)
+			return
+		}
+		newestFirst = newestFirst[min(offset, len(newestFirst)):]
+	}
+	body, _ := json.Marshal(twitcasting.CommentListContainer{
+		MovieId:  "100",
+		AllCount: len(fake.comments),
+		Comments: newestFirst[:min(limit, len(newestFirst))],
+	})
+	_, _ = w.Write(body)
+}
+
+func commentIds(comments []twitcasting.Comment) []string {
+	var ids []string
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	return ids
+}
+
+func TestCommentStreamPoll(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(3)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursors.json")}
+	stream := twitcasting.CreateCommentStream(locator.Comment, "100")
+	stream.CursorStore = store
+
+	comments, err := stream.Poll()
+	assert.Nil(t, err)
+	assert.Empty(t, comments)
+	assert.Equal(t, "3", stream.Cursor())
+
+	fake.post(2)
+	comments, err = stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4", "5"}, commentIds(comments))
+
+	// a burst larger than one page with a deleted comment in between
+	fake.post(70)
+	fake.remove("40")
+	comments, err = stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, 69, len(comments))
+	assert.Equal(t, "6", comments[0].Id)
+	assert.Equal(t, "75", comments[68].Id)
+	assert.NotContains(t, commentIds(comments), "40")
+
+	comments, err = stream.Poll()
+	assert.Nil(t, err)
+	assert.Empty(t, comments)
+
+	// a new stream resumes from the stored cursor
+	fake.post(1)
+	resumed := twitcasting.CreateCommentStream(locator.Comment, "100")
+	resumed.CursorStore = store
+	comments, err = resumed.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"76"}, commentIds(comments))
+	cursor, _ := store.LoadCursor("comments:100")
+	assert.Equal(t, "76", cursor)
+}
+
+func TestCommentStreamIncludeExisting(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	fake.post(3)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := twitcasting.CreateCommentStream(locator.Comment, "100")
+	stream.IncludeExisting = true
+
+	comments, err := stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, commentIds(comments))
+}
+
+func TestCommentStreamRun(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := twitcasting.CreateCommentStream(locator.Comment, "100")
+	stream.MinInterval = time.Millisecond
+	stream.MaxInterval = 4 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan twitcasting.Comment)
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx, out)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	fake.post(2)
+	assert.Equal(t, "1", (<-out).Id)
+	assert.Equal(t, "2", (<-out).Id)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestCommentStreamRunWithoutInterval(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := &twitcasting.CommentStream{CommentService: locator.Comment, MovieId: "100"}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, stream.RunFunc(ctx, func(twitcasting.Comment) {}))
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.LessOrEqual(t, fake.requests, 2)
+}
+
+func TestCommentStreamRunSavesDeliveredCursor(t *testing.T) {
+	fake := &fakeCommentsServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.MemoryCursorStore{}
+	stream := twitcasting.CreateCommentStream(locator.Comment, "100")
+	stream.CursorStore = store
+	stream.IncludeExisting = true
+	fake.post(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan twitcasting.Comment)
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx, out)
+	}()
+	assert.Equal(t, "1", (<-out).Id)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	cursor, _ := store.LoadCursor("comments:100")
+	assert.Equal(t, "1", cursor)
+
+	// the comments left in the interrupted batch are delivered after a restart
+	resumed := twitcasting.CreateCommentStream(locator.Comment, "100")
+	resumed.CursorStore = store
+	comments, err := resumed.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "3"}, commentIds(comments))
+}
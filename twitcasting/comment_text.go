@@ -0,0 +1,16 @@
+package twitcasting
+
+import "strings"
+
+// normalizeCommentText lowercases s and folds full-width ASCII to half-width.
+func normalizeCommentText(s string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if r >= '！' && r <= '～' {
+			return r - '！' + '!'
+		}
+		if r == '　' {
+			return ' '
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,56 @@
+package twitcasting
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runConcurrently calls fn for each index in [0, n) with at most concurrency calls running at once.
+// Indexes that have not started yet are skipped once ctx is done.
+func runConcurrently(ctx context.Context, n int, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case semaphore <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retry calls fn until it succeeds, maxRetries retries have failed or ctx is done.
+func retry(ctx context.Context, maxRetries int, interval time.Duration, fn func() error) error {
+	err := fn()
+	for attempt := 0; err != nil && attempt < maxRetries; attempt++ {
+		if sleepErr := sleepContext(ctx, interval); sleepErr != nil {
+			return err
+		}
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package twitcasting
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// CursorStore persists polling cursors such as slice ids so streams can resume after a restart.
+// LoadCursor returns an empty string when no cursor is stored for key.
+type CursorStore interface {
+	LoadCursor(key string) (string, error)
+	SaveCursor(key string, cursor string) error
+}
+
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]string
+}
+
+func (memoryCursorStore *MemoryCursorStore) LoadCursor(key string) (string, error) {
+	memoryCursorStore.mu.Lock()
+	defer memoryCursorStore.mu.Unlock()
+	return memoryCursorStore.cursors[key], nil
+}
+
+func (memoryCursorStore *MemoryCursorStore) SaveCursor(key string, cursor string) error {
+	memoryCursorStore.mu.Lock()
+	defer memoryCursorStore.mu.Unlock()
+	if memoryCursorStore.cursors == nil {
+		memoryCursorStore.cursors = map[string]string{}
+	}
+	memoryCursorStore.cursors[key] = cursor
+	return nil
+}
+
+// FileCursorStore keeps all cursors in a single JSON file at Path.
+type FileCursorStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (fileCursorStore *FileCursorStore) LoadCursor(key string) (string, error) {
+	fileCursorStore.mu.Lock()
+	defer fileCursorStore.mu.Unlock()
+	cursors, err := fileCursorStore.read()
+	if err != nil {
+		return "", err
+	}
+	return cursors[key], nil
+}
+
+func (fileCursorStore *FileCursorStore) SaveCursor(key string, cursor string) error {
+	fileCursorStore.mu.Lock()
+	defer fileCursorStore.mu.Unlock()
+	cursors, err := fileCursorStore.read()
+	if err != nil {
+		return err
+	}
+	cursors[key] = cursor
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := fileCursorStore.Path + ".tmp"
+	if err = os.WriteFile(temporaryPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, fileCursorStore.Path)
+}
+
+func (fileCursorStore *FileCursorStore) read() (map[string]string, error) {
+	cursors := map[string]string{}
+	data, err := os.ReadFile(fileCursorStore.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &cursors); err != nil {
+		return nil, err
+	}
+	return cursors, nil
+}
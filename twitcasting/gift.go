@@ -29,8 +29,28 @@ type GiftService ServiceBase
 // GetGifts https://apiv2-doc.twitcasting.tv/#get-gifts
 // GetGifts Requests can only be made using a Bearer Token.
 func (giftService *GiftService) GetGifts() (*GiftContainer, *ErrorResponse, error) {
+	return giftService.GetGiftsWith(GetGiftsRequest{})
+}
+
+// GetGiftsBySliceId https://apiv2-doc.twitcasting.tv/#get-gifts
+// GetGiftsBySliceId Requests can only be made using a Bearer Token.
+func (giftService *GiftService) GetGiftsBySliceId(sliceId string) (*GiftContainer, *ErrorResponse, error) {
+	return giftService.GetGiftsWith(GetGiftsRequest{SliceId: sliceId})
+}
+
+// GetGiftsWith https://apiv2-doc.twitcasting.tv/#get-gifts
+// GetGiftsWith Requests can only be made using a Bearer Token.
+func (giftService *GiftService) GetGiftsWith(request GetGiftsRequest) (*GiftContainer, *ErrorResponse, error) {
 	logger := *giftService.Logger
-	response, err := giftService.Client.get("/gifts", true)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetGifts", err)
+		return nil, nil, err
+	}
+	path := "/gifts"
+	if query := request.query(); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	response, err := giftService.Client.get(path, true)
 	if err != nil {
 		logger.Error("request failed for GetGifts", err)
 		return nil, nil, err
@@ -0,0 +1,219 @@
+package twitcasting
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParseItemMp parses Gift.ItemMp, which is a whole number such as "1,000". Values with a
+// fractional part are rejected so that totals stay exact.
+func ParseItemMp(itemMp string) (int64, error) {
+	value := strings.ReplaceAll(strings.TrimSpace(itemMp), ",", "")
+	mp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid item_mp %q: %w", itemMp, err)
+	}
+	return mp, nil
+}
+
+type GiftUserTotal struct {
+	Rank           int    `json:"rank"`
+	UserScreenId   string `json:"user_screen_id"`
+	UserName       string `json:"user_name"`
+	UserScreenName string `json:"user_screen_name"`
+	UserImage      string `json:"user_image"`
+	Count          int    `json:"count"`
+	Mp             int64  `json:"mp"`
+}
+
+type GiftItemTotal struct {
+	Rank     int    `json:"rank"`
+	ItemId   string `json:"item_id"`
+	ItemName string `json:"item_name"`
+	Count    int    `json:"count"`
+	Mp       int64  `json:"mp"`
+}
+
+// GiftLeaderboard totals gifts per user and per item. Gifts with an unparsable or fractional
+// ItemMp are counted with 0 MP and reported by InvalidMp. The zero value is ready to use.
+type GiftLeaderboard struct {
+	mu        sync.Mutex
+	users     map[string]*GiftUserTotal
+	items     map[string]*GiftItemTotal
+	count     int
+	mp        int64
+	invalidMp int
+}
+
+func (giftLeaderboard *GiftLeaderboard) Add(gifts ...Gift) {
+	giftLeaderboard.mu.Lock()
+	defer giftLeaderboard.mu.Unlock()
+	if giftLeaderboard.users == nil {
+		giftLeaderboard.users = map[string]*GiftUserTotal{}
+		giftLeaderboard.items = map[string]*GiftItemTotal{}
+	}
+	for _, gift := range gifts {
+		mp, err := ParseItemMp(gift.ItemMp)
+		if err != nil {
+			giftLeaderboard.invalidMp++
+		}
+		user, ok := giftLeaderboard.users[gift.UserScreenId]
+		if !ok {
+			user = &GiftUserTotal{UserScreenId: gift.UserScreenId}
+			giftLeaderboard.users[gift.UserScreenId] = user
+		}
+		// the latest gift has the current name and icon
+		user.UserName = gift.UserName
+		user.UserScreenName = gift.UserScreenName
+		user.UserImage = gift.UserImage
+		user.Count++
+		user.Mp += mp
+		item, ok := giftLeaderboard.items[gift.ItemId]
+		if !ok {
+			item = &GiftItemTotal{ItemId: gift.ItemId, ItemName: gift.ItemName}
+			giftLeaderboard.items[gift.ItemId] = item
+		}
+		item.Count++
+		item.Mp += mp
+		giftLeaderboard.count++
+		giftLeaderboard.mp += mp
+	}
+}
+
+// Count returns the number of gifts added.
+func (giftLeaderboard *GiftLeaderboard) Count() int {
+	giftLeaderboard.mu.Lock()
+	defer giftLeaderboard.mu.Unlock()
+	return giftLeaderboard.count
+}
+
+// TotalMp returns the MP of every gift added.
+func (giftLeaderboard *GiftLeaderboard) TotalMp() int64 {
+	giftLeaderboard.mu.Lock()
+	defer giftLeaderboard.mu.Unlock()
+	return giftLeaderboard.mp
+}
+
+// InvalidMp returns the number of gifts whose ItemMp could not be parsed.
+func (giftLeaderboard *GiftLeaderboard) InvalidMp() int {
+	giftLeaderboard.mu.Lock()
+	defer giftLeaderboard.mu.Unlock()
+	return giftLeaderboard.invalidMp
+}
+
+// Users returns the senders ranked by MP, then by count. Ties share a rank.
+func (giftLeaderboard *GiftLeaderboard) Users() []GiftUserTotal {
+	giftLeaderboard.mu.Lock()
+	users := make([]GiftUserTotal, 0, len(giftLeaderboard.users))
+	for _, user := range giftLeaderboard.users {
+		users = append(users, *user)
+	}
+	giftLeaderboard.mu.Unlock()
+	sort.Slice(users, func(i, j int) bool {
+		if c := compareGiftTotals(users[i].Mp, users[i].Count, users[j].Mp, users[j].Count); c != 0 {
+			return c > 0
+		}
+		return users[i].UserScreenId < users[j].UserScreenId
+	})
+	for i := range users {
+		users[i].Rank = i + 1
+		if i > 0 && compareGiftTotals(users[i].Mp, users[i].Count, users[i-1].Mp, users[i-1].Count) == 0 {
+			users[i].Rank = users[i-1].Rank
+		}
+	}
+	return users
+}
+
+// Items returns the items ranked by MP, then by count. Ties share a rank.
+func (giftLeaderboard *GiftLeaderboard) Items() []GiftItemTotal {
+	giftLeaderboard.mu.Lock()
+	items := make([]GiftItemTotal, 0, len(giftLeaderboard.items))
+	for _, item := range giftLeaderboard.items {
+		items = append(items, *item)
+	}
+	giftLeaderboard.mu.Unlock()
+	sort.Slice(items, func(i, j int) bool {
+		if c := compareGiftTotals(items[i].Mp, items[i].Count, items[j].Mp, items[j].Count); c != 0 {
+			return c > 0
+		}
+		return items[i].ItemId < items[j].ItemId
+	})
+	for i := range items {
+		items[i].Rank = i + 1
+		if i > 0 && compareGiftTotals(items[i].Mp, items[i].Count, items[i-1].Mp, items[i-1].Count) == 0 {
+			items[i].Rank = items[i-1].Rank
+		}
+	}
+	return items
+}
+
+func compareGiftTotals(mp1 int64, count1 int, mp2 int64, count2 int) int {
+	if c := cmp.Compare(mp1, mp2); c != 0 {
+		return c
+	}
+	return cmp.Compare(count1, count2)
+}
+
+// WriteJSON writes the totals together with both rankings.
+func (giftLeaderboard *GiftLeaderboard) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(struct {
+		Count     int             `json:"count"`
+		TotalMp   int64           `json:"total_mp"`
+		InvalidMp int             `json:"invalid_mp"`
+		Users     []GiftUserTotal `json:"users"`
+		Items     []GiftItemTotal `json:"items"`
+	}{giftLeaderboard.Count(), giftLeaderboard.TotalMp(), giftLeaderboard.InvalidMp(), giftLeaderboard.Users(), giftLeaderboard.Items()})
+}
+
+func (giftLeaderboard *GiftLeaderboard) WriteUsersCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"rank", "user_screen_id", "user_name", "count", "mp"})
+	if err != nil {
+		return err
+	}
+	for _, user := range giftLeaderboard.Users() {
+		err = writer.Write([]string{
+			strconv.Itoa(user.Rank),
+			user.UserScreenId,
+			user.UserName,
+			strconv.Itoa(user.Count),
+			strconv.FormatInt(user.Mp, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (giftLeaderboard *GiftLeaderboard) WriteItemsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"rank", "item_id", "item_name", "count", "mp"})
+	if err != nil {
+		return err
+	}
+	for _, item := range giftLeaderboard.Items() {
+		err = writer.Write([]string{
+			strconv.Itoa(item.Rank),
+			item.ItemId,
+			item.ItemName,
+			strconv.Itoa(item.Count),
+			strconv.FormatInt(item.Mp, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,75 @@
+package twitcasting_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseItemMp(t *testing.T) {
+	mp, err := twitcasting.ParseItemMp("1,000")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), mp)
+	mp, err = twitcasting.ParseItemMp(" 30 ")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), mp)
+	_, err = twitcasting.ParseItemMp("0.5")
+	assert.NotNil(t, err)
+	_, err = twitcasting.ParseItemMp("")
+	assert.NotNil(t, err)
+}
+
+func leaderboardGift(userScreenId string, itemId string, itemMp string) twitcasting.Gift {
+	return twitcasting.Gift{ItemId: itemId, ItemName: itemId + " name", ItemMp: itemMp, UserScreenId: userScreenId, UserName: userScreenId + " name"}
+}
+
+func TestGiftLeaderboard(t *testing.T) {
+	leaderboard := &twitcasting.GiftLeaderboard{}
+	leaderboard.Add(
+		leaderboardGift("alice", "tea", "10"),
+		leaderboardGift("bob", "cake", "1,000"),
+		leaderboardGift("alice", "tea", "10"),
+		leaderboardGift("carol", "tea", "10"),
+		leaderboardGift("dave", "tea", "10"),
+		leaderboardGift("erin", "unknown", "?"),
+	)
+	assert.Equal(t, 6, leaderboard.Count())
+	assert.Equal(t, int64(1040), leaderboard.TotalMp())
+	assert.Equal(t, 1, leaderboard.InvalidMp())
+
+	users := leaderboard.Users()
+	var ranking []string
+	var ranks []int
+	for _, user := range users {
+		ranking = append(ranking, user.UserScreenId)
+		ranks = append(ranks, user.Rank)
+	}
+	assert.Equal(t, []string{"bob", "alice", "carol", "dave", "erin"}, ranking)
+	assert.Equal(t, []int{1, 2, 3, 3, 5}, ranks)
+	assert.Equal(t, 2, users[1].Count)
+	assert.Equal(t, int64(20), users[1].Mp)
+
+	items := leaderboard.Items()
+	assert.Equal(t, twitcasting.GiftItemTotal{Rank: 1, ItemId: "cake", ItemName: "cake name", Count: 1, Mp: 1000}, items[0])
+	assert.Equal(t, twitcasting.GiftItemTotal{Rank: 2, ItemId: "tea", ItemName: "tea name", Count: 4, Mp: 40}, items[1])
+
+	var buf bytes.Buffer
+	assert.Nil(t, leaderboard.WriteUsersCSV(&buf))
+	assert.Equal(t, "rank,user_screen_id,user_name,count,mp\n1,bob,bob name,1,1000\n2,alice,alice name,2,20\n3,carol,carol name,1,10\n3,dave,dave name,1,10\n5,erin,erin name,1,0\n", buf.String())
+	buf.Reset()
+	assert.Nil(t, leaderboard.WriteItemsCSV(&buf))
+	assert.Equal(t, "rank,item_id,item_name,count,mp\n1,cake,cake name,1,1000\n2,tea,tea name,4,40\n3,unknown,unknown name,1,0\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, leaderboard.WriteJSON(&buf))
+	var exported struct {
+		Count   int                         `json:"count"`
+		TotalMp int64                       `json:"total_mp"`
+		Users   []twitcasting.GiftUserTotal `json:"users"`
+	}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, 6, exported.Count)
+	assert.Equal(t, users, exported.Users)
+}
@@ -0,0 +1,187 @@
+package twitcasting
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// GiftBatch is the data of GiftResponder.BatchTemplate.
+type GiftBatch struct {
+	Gifts []Gift
+	// UserNames has each sender once, in the order of their first gift.
+	UserNames []string
+	ItemNames []string
+}
+
+// GiftResponder posts thank-you comments for gifts through a CommentQueue. Gifts arriving within
+// BurstWindow of each other are thanked in one comment, and a user is thanked at most once per UserCooldown.
+// The cooldown starts once the comment is posted, so a failed comment leaves the users unthanked.
+type GiftResponder struct {
+	Queue   *CommentQueue
+	MovieId string
+	// Template is a text/template executed with the Gift when a single gift is thanked.
+	Template string
+	// BatchTemplate is a text/template executed with a GiftBatch. It has a join function.
+	BatchTemplate string
+	BurstWindow   time.Duration
+	UserCooldown  time.Duration
+	Sns           CommentSns
+
+	mu      sync.Mutex
+	thanked map[string]time.Time
+	// single and batch are parsed from singleSource and batchSource.
+	single       *template.Template
+	singleSource string
+	batch        *template.Template
+	batchSource  string
+}
+
+func CreateGiftResponder(queue *CommentQueue, movieId string) *GiftResponder {
+	return &GiftResponder{
+		Queue:         queue,
+		MovieId:       movieId,
+		Template:      "{{.UserName}}さん、{{.ItemName}}ありがとう！",
+		BatchTemplate: `{{join .UserNames "さん、"}}さん、ギフトありがとう！`,
+		BurstWindow:   3 * time.Second,
+		UserCooldown:  time.Minute,
+		Sns:           CommentSnsNone,
+	}
+}
+
+// Run thanks the gifts received from gifts until the channel is closed or ctx is done.
+// A burst still collecting when the channel is closed is thanked before Run returns nil.
+// The templates are checked with Validate before any gift is read.
+func (giftResponder *GiftResponder) Run(ctx context.Context, gifts <-chan Gift) error {
+	logger := *giftResponder.Queue.CommentService.Logger
+	if err := giftResponder.Validate(); err != nil {
+		return err
+	}
+	var burst []Gift
+	var flush <-chan time.Time
+	respond := func() {
+		if _, err := giftResponder.Respond(ctx, burst); err != nil {
+			logger.Warn("respond failed for GiftResponder", err)
+		}
+		burst = nil
+		flush = nil
+	}
+	for {
+		select {
+		case gift, ok := <-gifts:
+			if !ok {
+				if len(burst) > 0 {
+					respond()
+				}
+				return nil
+			}
+			burst = append(burst, gift)
+			if flush == nil {
+				flush = time.After(giftResponder.BurstWindow)
+			}
+		case <-flush:
+			respond()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Validate parses Template and BatchTemplate. They are parsed again only after they change.
+func (giftResponder *GiftResponder) Validate() error {
+	_, _, err := giftResponder.templates()
+	return err
+}
+
+func (giftResponder *GiftResponder) templates() (*template.Template, *template.Template, error) {
+	giftResponder.mu.Lock()
+	defer giftResponder.mu.Unlock()
+	if giftResponder.single == nil || giftResponder.singleSource != giftResponder.Template {
+		single, err := template.New("gift").Parse(giftResponder.Template)
+		if err != nil {
+			return nil, nil, err
+		}
+		giftResponder.single, giftResponder.singleSource = single, giftResponder.Template
+	}
+	if giftResponder.batch == nil || giftResponder.batchSource != giftResponder.BatchTemplate {
+		batch, err := template.New("gifts").Funcs(template.FuncMap{"join": strings.Join}).Parse(giftResponder.BatchTemplate)
+		if err != nil {
+			return nil, nil, err
+		}
+		giftResponder.batch, giftResponder.batchSource = batch, giftResponder.BatchTemplate
+	}
+	return giftResponder.single, giftResponder.batch, nil
+}
+
+// Respond posts one thank-you comment for gifts, leaving out the users in cooldown, and returns it.
+// It returns "" when every sender is in cooldown.
+func (giftResponder *GiftResponder) Respond(ctx context.Context, gifts []Gift) (string, error) {
+	gifts = giftResponder.outOfCooldown(gifts, time.Now())
+	if len(gifts) == 0 {
+		return "", nil
+	}
+	single, batch, err := giftResponder.templates()
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	if len(gifts) == 1 {
+		err = single.Execute(&builder, gifts[0])
+	} else {
+		err = batch.Execute(&builder, newGiftBatch(gifts))
+	}
+	if err != nil {
+		return "", err
+	}
+	message := builder.String()
+	if _, err = giftResponder.Queue.Send(ctx, giftResponder.MovieId, message, giftResponder.Sns); err != nil {
+		return message, err
+	}
+	giftResponder.startCooldown(gifts, time.Now())
+	return message, nil
+}
+
+// outOfCooldown drops the gifts of users thanked within UserCooldown.
+func (giftResponder *GiftResponder) outOfCooldown(gifts []Gift, now time.Time) []Gift {
+	giftResponder.mu.Lock()
+	defer giftResponder.mu.Unlock()
+	var kept []Gift
+	for _, gift := range gifts {
+		if thanked, ok := giftResponder.thanked[gift.UserScreenId]; ok && now.Sub(thanked) < giftResponder.UserCooldown {
+			continue
+		}
+		kept = append(kept, gift)
+	}
+	return kept
+}
+
+// startCooldown starts the cooldown of the senders of gifts.
+func (giftResponder *GiftResponder) startCooldown(gifts []Gift, now time.Time) {
+	giftResponder.mu.Lock()
+	defer giftResponder.mu.Unlock()
+	if giftResponder.thanked == nil {
+		giftResponder.thanked = map[string]time.Time{}
+	}
+	for _, gift := range gifts {
+		giftResponder.thanked[gift.UserScreenId] = now
+	}
+}
+
+func newGiftBatch(gifts []Gift) GiftBatch {
+	batch := GiftBatch{Gifts: gifts}
+	users := map[string]struct{}{}
+	items := map[string]struct{}{}
+	for _, gift := range gifts {
+		if _, ok := users[gift.UserScreenId]; !ok {
+			users[gift.UserScreenId] = struct{}{}
+			batch.UserNames = append(batch.UserNames, gift.UserName)
+		}
+		if _, ok := items[gift.ItemId]; !ok {
+			items[gift.ItemId] = struct{}{}
+			batch.ItemNames = append(batch.ItemNames, gift.ItemName)
+		}
+	}
+	return batch
+}
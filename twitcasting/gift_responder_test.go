@@ -0,0 +1,108 @@
+package twitcasting_test
+
+import (
+	"context"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGiftResponderRespond(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 0
+	queue.DedupeWindow = 0
+	responder := twitcasting.CreateGiftResponder(queue, "100")
+	responder.Template = "{{.UserName}}さん、{{.ItemName}}ありがとう！{{if .Message}}「{{.Message}}」{{end}}"
+
+	alice := testGift("1", "alice", "tea")
+	alice.Message = "おつかれ"
+	message, err := responder.Respond(context.Background(), []twitcasting.Gift{alice})
+	assert.Nil(t, err)
+	assert.Equal(t, "aliceさん、teaありがとう！「おつかれ」", message)
+
+	// alice is in cooldown, bob sent two gifts
+	message, err = responder.Respond(context.Background(), []twitcasting.Gift{
+		testGift("2", "bob", "tea"),
+		alice,
+		testGift("3", "carol", "star"),
+		testGift("4", "bob", "star"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "bobさん、carolさん、ギフトありがとう！", message)
+
+	message, err = responder.Respond(context.Background(), []twitcasting.Gift{alice})
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+	assert.Equal(t, []string{"aliceさん、teaありがとう！「おつかれ」", "bobさん、carolさん、ギフトありがとう！"}, fake.messages())
+
+	// dave and erin stay out of cooldown while the template is broken
+	dave := testGift("5", "dave", "tea")
+	erin := testGift("6", "erin", "tea")
+	responder.BatchTemplate = "{{.Missing"
+	assert.NotNil(t, responder.Validate())
+	_, err = responder.Respond(context.Background(), []twitcasting.Gift{dave, erin})
+	assert.NotNil(t, err)
+	responder.BatchTemplate = "{{len .Gifts}} gifts"
+	message, err = responder.Respond(context.Background(), []twitcasting.Gift{dave, erin})
+	assert.Nil(t, err)
+	assert.Equal(t, "2 gifts", message)
+}
+
+func TestGiftResponderFailedSend(t *testing.T) {
+	fake := &fakeChatServer{}
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"code":500,"message":"Internal Server Error"}}`))
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 0
+	responder := twitcasting.CreateGiftResponder(queue, "100")
+
+	alice := testGift("1", "alice", "tea")
+	_, err := responder.Respond(context.Background(), []twitcasting.Gift{alice})
+	assert.NotNil(t, err)
+	message, err := responder.Respond(context.Background(), []twitcasting.Gift{alice})
+	assert.Nil(t, err)
+	assert.Equal(t, "aliceさん、teaありがとう！", message)
+	assert.Equal(t, []string{"aliceさん、teaありがとう！"}, fake.messages())
+}
+
+func TestGiftResponderRun(t *testing.T) {
+	fake := &fakeChatServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	queue := twitcasting.CreateCommentQueue(locator.Comment)
+	queue.Interval = 0
+	responder := twitcasting.CreateGiftResponder(queue, "100")
+	responder.BurstWindow = 20 * time.Millisecond
+	responder.BatchTemplate = "{{len .Gifts}} gifts from {{join .UserNames \", \"}}"
+
+	gifts := make(chan twitcasting.Gift)
+	done := make(chan error)
+	go func() {
+		done <- responder.Run(context.Background(), gifts)
+	}()
+	gifts <- testGift("1", "alice", "tea")
+	gifts <- testGift("2", "bob", "tea")
+	time.Sleep(50 * time.Millisecond)
+	gifts <- testGift("3", "carol", "tea")
+	close(gifts)
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{"2 gifts from alice, bob", "carolさん、teaありがとう！"}, fake.messages())
+}
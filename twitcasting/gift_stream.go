@@ -0,0 +1,247 @@
+package twitcasting
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// giftsCursorKey is the CursorStore key of GiftStream. Gifts are those of the Bearer Token's user.
+const giftsCursorKey = "gifts"
+
+type GiftDedupe string
+
+const (
+	// GiftDedupeOff delivers every gift returned by the API.
+	GiftDedupeOff GiftDedupe = "off"
+	// GiftDedupeSlice drops a gift only when the same slice is fetched again, as after an
+	// interrupted RunFunc, and the gift was already delivered from it. Gifts after the cursor
+	// are never dropped. An empty Dedupe means GiftDedupeSlice.
+	GiftDedupeSlice GiftDedupe = "slice"
+	// GiftDedupeWindow drops a gift when a gift with the same fingerprint, arrival window
+	// included, was delivered by an earlier poll. Identical gifts in the same poll are repeats
+	// and are kept, but a repeated gift arriving in the same window as the first is dropped.
+	GiftDedupeWindow GiftDedupe = "window"
+)
+
+// GiftFingerprint identifies a gift by its id, item, sender and message, since Gift.Id alone is
+// not unique, and by the window of length window that arrived falls in. A window of 0 leaves the
+// arrival out.
+func GiftFingerprint(gift Gift, arrived time.Time, window time.Duration) string {
+	fields := []string{gift.Id, gift.ItemId, gift.UserScreenId, gift.Message}
+	if window > 0 {
+		fields = append(fields, strconv.FormatInt(arrived.Truncate(window).Unix(), 10))
+	}
+	hash := sha1.New()
+	for _, field := range fields {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GiftStream polls GetGiftsBySliceId and delivers the gifts received since the previous poll.
+type GiftStream struct {
+	GiftService *GiftService
+	// Interval defaults to 5 seconds when it is not positive.
+	Interval time.Duration
+	// CursorStore is optional. The slice id is saved under "gifts" so a restart resumes from it.
+	CursorStore CursorStore
+	// IncludeExisting delivers the gifts returned by GetGifts when no cursor is stored.
+	IncludeExisting bool
+	Dedupe          GiftDedupe
+	// DedupeWindow is the length of the arrival windows of GiftDedupeWindow.
+	DedupeWindow time.Duration
+
+	cursor string
+	// delivered counts the gifts delivered from deliveredCursor, by fingerprint without arrival.
+	deliveredCursor string
+	delivered       map[string]int
+	// arrived maps the fingerprints delivered in the current window to the window.
+	arrived map[string]time.Time
+}
+
+// defaultGiftStreamInterval is used by Run when Interval is not positive.
+const defaultGiftStreamInterval = 5 * time.Second
+
+func CreateGiftStream(giftService *GiftService) *GiftStream {
+	return &GiftStream{
+		GiftService:  giftService,
+		Interval:     defaultGiftStreamInterval,
+		Dedupe:       GiftDedupeSlice,
+		DedupeWindow: time.Minute,
+	}
+}
+
+// Cursor returns the slice id the next poll starts from.
+func (giftStream *GiftStream) Cursor() string {
+	return giftStream.cursor
+}
+
+// Run sends new gifts to out until ctx is done and returns ctx.Err().
+func (giftStream *GiftStream) Run(ctx context.Context, out chan<- Gift) error {
+	return giftStream.run(ctx, func(gift Gift) bool {
+		select {
+		case out <- gift:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// RunFunc calls fn for each new gift until ctx is done and returns ctx.Err().
+// The cursor is saved once every gift of a poll is delivered, so the gifts of an interrupted poll
+// are fetched again when the stream is run again. GiftDedupeSlice then drops those already delivered.
+func (giftStream *GiftStream) RunFunc(ctx context.Context, fn func(Gift)) error {
+	return giftStream.run(ctx, func(gift Gift) bool {
+		fn(gift)
+		return true
+	})
+}
+
+// run records each gift for dedupe only once deliver has accepted it.
+func (giftStream *GiftStream) run(ctx context.Context, deliver func(Gift) bool) error {
+	logger := *giftStream.GiftService.Logger
+	interval := giftStream.Interval
+	if interval <= 0 {
+		interval = defaultGiftStreamInterval
+	}
+	for {
+		gifts, sliceId, err := giftStream.fetch()
+		if err != nil {
+			logger.Warn("poll failed for GiftStream", err)
+		}
+		for _, gift := range gifts {
+			if ctx.Err() != nil || !deliver(gift) {
+				return ctx.Err()
+			}
+			giftStream.remember([]Gift{gift}, time.Now())
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			if err = giftStream.advance(sliceId); err != nil {
+				logger.Warn("save cursor failed for GiftStream", err)
+			}
+		}
+		if err = sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll fetches the gifts after the cursor, drops the duplicates according to Dedupe and
+// advances the cursor to the returned slice id.
+func (giftStream *GiftStream) Poll() ([]Gift, error) {
+	gifts, sliceId, err := giftStream.fetch()
+	if err != nil {
+		return nil, err
+	}
+	giftStream.remember(gifts, time.Now())
+	return gifts, giftStream.advance(sliceId)
+}
+
+// fetch returns the gifts after the cursor and the slice id to advance to, without moving the cursor.
+func (giftStream *GiftStream) fetch() ([]Gift, string, error) {
+	var container *GiftContainer
+	var err error
+	if giftStream.cursor == "" {
+		if giftStream.CursorStore != nil {
+			if giftStream.cursor, err = giftStream.CursorStore.LoadCursor(giftsCursorKey); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	initial := giftStream.cursor == ""
+	if initial {
+		container, _, err = giftStream.GiftService.GetGifts()
+	} else {
+		container, _, err = giftStream.GiftService.GetGiftsBySliceId(giftStream.cursor)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	gifts := giftStream.dedupe(container.Gifts, time.Now())
+	if initial && !giftStream.IncludeExisting {
+		// the existing gifts are recorded even when they are not delivered
+		giftStream.remember(gifts, time.Now())
+		gifts = nil
+	}
+	return gifts, container.SliceId, nil
+}
+
+// advance moves the cursor to sliceId.
+func (giftStream *GiftStream) advance(sliceId string) error {
+	if sliceId == "" || sliceId == giftStream.cursor {
+		return nil
+	}
+	giftStream.cursor = sliceId
+	if giftStream.CursorStore != nil {
+		return giftStream.CursorStore.SaveCursor(giftsCursorKey, giftStream.cursor)
+	}
+	return nil
+}
+
+func (giftStream *GiftStream) dedupe(gifts []Gift, now time.Time) []Gift {
+	var kept []Gift
+	switch giftStream.Dedupe {
+	case GiftDedupeOff:
+		return gifts
+	case GiftDedupeWindow:
+		for _, gift := range gifts {
+			if _, ok := giftStream.arrived[GiftFingerprint(gift, now, giftStream.DedupeWindow)]; !ok {
+				kept = append(kept, gift)
+			}
+		}
+	default:
+		if giftStream.deliveredCursor != giftStream.cursor {
+			return gifts
+		}
+		// a gift sent twice in the slice is dropped only as many times as it was delivered
+		remaining := map[string]int{}
+		for fingerprint, count := range giftStream.delivered {
+			remaining[fingerprint] = count
+		}
+		for _, gift := range gifts {
+			fingerprint := GiftFingerprint(gift, now, 0)
+			if remaining[fingerprint] > 0 {
+				remaining[fingerprint]--
+				continue
+			}
+			kept = append(kept, gift)
+		}
+	}
+	return kept
+}
+
+// remember records the delivered gifts for dedupe.
+func (giftStream *GiftStream) remember(gifts []Gift, now time.Time) {
+	switch giftStream.Dedupe {
+	case GiftDedupeOff:
+	case GiftDedupeWindow:
+		window := now.Truncate(giftStream.DedupeWindow)
+		if giftStream.arrived == nil {
+			giftStream.arrived = map[string]time.Time{}
+		}
+		for fingerprint, arrived := range giftStream.arrived {
+			if !arrived.Equal(window) {
+				delete(giftStream.arrived, fingerprint)
+			}
+		}
+		for _, gift := range gifts {
+			giftStream.arrived[GiftFingerprint(gift, now, giftStream.DedupeWindow)] = window
+		}
+	default:
+		if giftStream.delivered == nil || giftStream.deliveredCursor != giftStream.cursor {
+			giftStream.deliveredCursor = giftStream.cursor
+			giftStream.delivered = map[string]int{}
+		}
+		for _, gift := range gifts {
+			giftStream.delivered[GiftFingerprint(gift, now, 0)]++
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeGiftsServer struct {
+	mu       sync.Mutex
+	gifts    []twitcasting.Gift // oldest first
+	sliceIds []int
+	nextId   int
+	requests []string
+}
+
+// send appends gifts with increasing slice ids. The gift ids are kept as given since they are not unique.
+func (fake *fakeGiftsServer) send(gifts ...twitcasting.Gift) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, gift := range gifts {
+		fake.nextId++
+		fake.gifts = append(fake.gifts, gift)
+		fake.sliceIds = append(fake.sliceIds, fake.nextId)
+	}
+}
+
+// ServeHTTP returns the gifts after slice_id, or the latest ten without it.
+func (fake *fakeGiftsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests = append(fake.requests, r.URL.RawQuery)
+	container := twitcasting.GiftContainer{SliceId: strconv.Itoa(fake.nextId), Gifts: []twitcasting.Gift{}}
+	if r.URL.Query().Has("slice_id") {
+		sliceId, _ := strconv.Atoi(r.URL.Query().Get("slice_id"))
+		for i, gift := range fake.gifts {
+			if fake.sliceIds[i] > sliceId {
+				container.Gifts = append(container.Gifts, gift)
+			}
+		}
+	} else {
+		container.Gifts = append(container.Gifts, fake.gifts[max(len(fake.gifts)-10, 0):]...)
+	}
+	body, _ := json.Marshal(container)
+	_, _ = w.Write(body)
+}
+
+func testGift(id string, userScreenId string, itemId string) twitcasting.Gift {
+	return twitcasting.Gift{Id: id, ItemId: itemId, ItemName: itemId, ItemMp: "10", UserScreenId: userScreenId, UserName: userScreenId}
+}
+
+func giftIds(gifts []twitcasting.Gift) []string {
+	var ids []string
+	for _, gift := range gifts {
+		ids = append(ids, gift.Id)
+	}
+	return ids
+}
+
+func TestGiftStreamPoll(t *testing.T) {
+	fake := &fakeGiftsServer{}
+	fake.send(testGift("1", "alice", "tea"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursors.json")}
+	stream := twitcasting.CreateGiftStream(locator.Gift)
+	stream.CursorStore = store
+
+	gifts, err := stream.Poll()
+	assert.Nil(t, err)
+	assert.Empty(t, gifts)
+	assert.Equal(t, "1", stream.Cursor())
+
+	fake.send(testGift("2", "bob", "tea"), testGift("3", "alice", "star"))
+	gifts, err = stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "3"}, giftIds(gifts))
+	gifts, err = stream.Poll()
+	assert.Nil(t, err)
+	assert.Empty(t, gifts)
+
+	// a new stream resumes from the stored slice id
+	fake.send(testGift("4", "carol", "tea"))
+	resumed := twitcasting.CreateGiftStream(locator.Gift)
+	resumed.CursorStore = store
+	gifts, err = resumed.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4"}, giftIds(gifts))
+	cursor, _ := store.LoadCursor("gifts")
+	assert.Equal(t, "4", cursor)
+	assert.Equal(t, []string{"", "slice_id=1", "slice_id=3", "slice_id=3"}, fake.requests)
+}
+
+func TestGiftStreamRun(t *testing.T) {
+	fake := &fakeGiftsServer{}
+	fake.send(testGift("1", "alice", "tea"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := twitcasting.CreateGiftStream(locator.Gift)
+	stream.IncludeExisting = true
+	stream.Interval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan twitcasting.Gift)
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx, out)
+	}()
+	assert.Equal(t, "1", (<-out).Id)
+	fake.send(testGift("2", "bob", "tea"))
+	assert.Equal(t, "2", (<-out).Id)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestGiftStreamRunSavesCursorAfterDelivery(t *testing.T) {
+	fake := &fakeGiftsServer{}
+	fake.send(testGift("1", "alice", "tea"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursors.json")}
+	stream := twitcasting.CreateGiftStream(locator.Gift)
+	stream.CursorStore = store
+	_, err := stream.Poll()
+	assert.Nil(t, err)
+
+	// the stream stops after the first gift of the batch
+	fake.send(testGift("2", "bob", "tea"), testGift("3", "carol", "star"))
+	ctx, cancel := context.WithCancel(context.Background())
+	var received []string
+	err = stream.RunFunc(ctx, func(gift twitcasting.Gift) {
+		received = append(received, gift.Id)
+		cancel()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []string{"2"}, received)
+	assert.Equal(t, "1", stream.Cursor())
+	cursor, _ := store.LoadCursor("gifts")
+	assert.Equal(t, "1", cursor)
+
+	// the batch is fetched again and the delivered gift is dropped
+	gifts, err := stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3"}, giftIds(gifts))
+	cursor, _ = store.LoadCursor("gifts")
+	assert.Equal(t, "3", cursor)
+}
+
+func TestGiftStreamRunKeepsUndeliveredGift(t *testing.T) {
+	fake := &fakeGiftsServer{}
+	fake.send(testGift("1", "alice", "tea"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := twitcasting.CreateGiftStream(locator.Gift)
+	_, err := stream.Poll()
+	assert.Nil(t, err)
+
+	// ctx ends while the stream is blocked sending the second gift
+	fake.send(testGift("2", "bob", "tea"), testGift("3", "carol", "star"))
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan twitcasting.Gift)
+	done := make(chan error)
+	go func() {
+		done <- stream.Run(ctx, out)
+	}()
+	assert.Equal(t, "2", (<-out).Id)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	gifts, err := stream.Poll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3"}, giftIds(gifts))
+}
+
+func TestGiftStreamRunWithoutInterval(t *testing.T) {
+	fake := &fakeGiftsServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	stream := &twitcasting.GiftStream{GiftService: locator.Gift}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, stream.RunFunc(ctx, func(twitcasting.Gift) {}))
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestGiftStreamDedupe(t *testing.T) {
+	alice := testGift("1", "alice", "tea")
+	bob := testGift("1", "bob", "tea")
+	carol := testGift("2", "carol", "star")
+	dave := testGift("3", "dave", "star")
+	pages := []twitcasting.GiftContainer{
+		{SliceId: "1", Gifts: []twitcasting.Gift{alice}},
+		// alice is returned again and bob sent the same gift twice
+		{SliceId: "3", Gifts: []twitcasting.Gift{alice, bob, bob}},
+		// bob sent it once more
+		{SliceId: "4", Gifts: []twitcasting.Gift{bob}},
+		// the slice id does not move, so slice 4 is fetched again with carol in it
+		{SliceId: "4", Gifts: []twitcasting.Gift{carol}},
+		{SliceId: "5", Gifts: []twitcasting.Gift{carol, dave}},
+	}
+	expected := map[twitcasting.GiftDedupe][]string{
+		twitcasting.GiftDedupeSlice:  {"alice", "bob", "bob", "bob", "carol", "dave"},
+		twitcasting.GiftDedupeWindow: {"bob", "bob", "carol", "dave"},
+		twitcasting.GiftDedupeOff:    {"alice", "bob", "bob", "bob", "carol", "carol", "dave"},
+	}
+	for dedupe, names := range expected {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := json.Marshal(pages[requests])
+			requests++
+			_, _ = w.Write(body)
+		}))
+		locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+		stream := twitcasting.CreateGiftStream(locator.Gift)
+		stream.Dedupe = dedupe
+		// a window long enough that the test never crosses one
+		stream.DedupeWindow = 1 << 62
+
+		var received []string
+		for range pages {
+			gifts, err := stream.Poll()
+			assert.Nil(t, err)
+			for _, gift := range gifts {
+				received = append(received, gift.UserScreenId)
+			}
+		}
+		assert.Equal(t, names, received, dedupe)
+		server.Close()
+	}
+}
+
+func TestGiftFingerprint(t *testing.T) {
+	alice := testGift("1", "alice", "tea")
+	arrived := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+	assert.NotEqual(t, twitcasting.GiftFingerprint(alice, arrived, 0), twitcasting.GiftFingerprint(testGift("1", "bob", "tea"), arrived, 0))
+	assert.Equal(t, twitcasting.GiftFingerprint(alice, arrived, 0), twitcasting.GiftFingerprint(testGift("1", "alice", "tea"), arrived.Add(time.Hour), 0))
+	assert.Equal(t, twitcasting.GiftFingerprint(alice, arrived, time.Minute), twitcasting.GiftFingerprint(alice, arrived.Add(40*time.Second), time.Minute))
+	assert.NotEqual(t, twitcasting.GiftFingerprint(alice, arrived, time.Minute), twitcasting.GiftFingerprint(alice, arrived.Add(50*time.Second), time.Minute))
+}
@@ -52,3 +52,45 @@ func TestGetGifts(t *testing.T) {
 	assert.Equal(t, &expected2, errorResponse)
 	server.Close()
 }
+
+func TestGetGiftsBySliceId(t *testing.T) {
+	expected := twitcasting.GiftContainer{
+		SliceId: "12",
+		Gifts: []twitcasting.Gift{
+			{
+				Id:             "12",
+				Message:        "test_message",
+				ItemId:         "tea",
+				ItemMp:         "1",
+				ItemName:       "お茶",
+				UserScreenId:   "test_screen_id",
+				UserScreenName: "test_screen_name",
+				UserName:       "test_user",
+			},
+		},
+	}
+	server := CreateTestSever(t, expected, "", url.Values{"slice_id": []string{"10"}}, true, http.StatusOK)
+	server.Start()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	giftsResponse, errorResponse, err := locator.Gift.GetGiftsBySliceId("10")
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, &expected, giftsResponse)
+	server.Close()
+
+	expected2 := twitcasting.ErrorResponse{
+		Error: twitcasting.Error{
+			Code:    1000,
+			Message: "Invalid token",
+		},
+	}
+	server = CreateTestSever(t, expected2, "", url.Values{"slice_id": []string{"10"}}, true, http.StatusBadRequest)
+	server.Start()
+	locator = CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	giftsResponse, errorResponse, err = locator.Gift.GetGiftsBySliceId("10")
+	assert.Nil(t, giftsResponse)
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "error response")
+	assert.Equal(t, &expected2, errorResponse)
+	server.Close()
+}
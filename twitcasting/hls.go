@@ -0,0 +1,268 @@
+package twitcasting
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidHlsPlaylist = errors.New("invalid hls playlist")
+
+type HlsVariant struct {
+	Url              string  `json:"url"`
+	Bandwidth        int     `json:"bandwidth"`
+	AverageBandwidth int     `json:"average_bandwidth"`
+	Resolution       string  `json:"resolution"`
+	Codecs           string  `json:"codecs"`
+	FrameRate        float64 `json:"frame_rate"`
+}
+
+type HlsMasterPlaylist struct {
+	Url      string       `json:"url"`
+	Variants []HlsVariant `json:"variants"`
+}
+
+type HlsSegment struct {
+	Url           string  `json:"url"`
+	Sequence      int     `json:"sequence"`
+	Duration      float64 `json:"duration"`
+	Title         string  `json:"title"`
+	Discontinuity bool    `json:"discontinuity"`
+}
+
+type HlsMediaPlaylist struct {
+	Url                   string       `json:"url"`
+	Version               int          `json:"version"`
+	TargetDuration        int          `json:"target_duration"`
+	MediaSequence         int          `json:"media_sequence"`
+	DiscontinuitySequence int          `json:"discontinuity_sequence"`
+	PlaylistType          string       `json:"playlist_type"`
+	EndList               bool         `json:"end_list"`
+	Segments              []HlsSegment `json:"segments"`
+}
+
+// HlsPlaylist holds either a master or a media playlist.
+type HlsPlaylist struct {
+	Master *HlsMasterPlaylist
+	Media  *HlsMediaPlaylist
+}
+
+// BestVariant returns the variant with the highest bandwidth.
+func (hlsMasterPlaylist *HlsMasterPlaylist) BestVariant() *HlsVariant {
+	var best *HlsVariant
+	for i := range hlsMasterPlaylist.Variants {
+		if best == nil || hlsMasterPlaylist.Variants[i].Bandwidth > best.Bandwidth {
+			best = &hlsMasterPlaylist.Variants[i]
+		}
+	}
+	return best
+}
+
+// Duration returns the sum of all segment durations in seconds.
+func (hlsMediaPlaylist *HlsMediaPlaylist) Duration() float64 {
+	duration := 0.0
+	for _, segment := range hlsMediaPlaylist.Segments {
+		duration += segment.Duration
+	}
+	return duration
+}
+
+// ParseHlsPlaylist parses a m3u8 playlist. Relative uris are resolved against playlistUrl.
+func ParseHlsPlaylist(r io.Reader, playlistUrl string) (*HlsPlaylist, error) {
+	base, err := url.Parse(playlistUrl)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() || strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")) != "#EXTM3U" {
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: missing #EXTM3U header", ErrInvalidHlsPlaylist)
+	}
+	master := &HlsMasterPlaylist{Url: playlistUrl}
+	media := &HlsMediaPlaylist{Url: playlistUrl}
+	isMaster := false
+	var variant *HlsVariant
+	var segment *HlsSegment
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			uri, err := base.Parse(line)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidHlsPlaylist, err)
+			}
+			if variant != nil {
+				variant.Url = uri.String()
+				master.Variants = append(master.Variants, *variant)
+				variant = nil
+			} else if segment != nil {
+				segment.Url = uri.String()
+				segment.Sequence = media.MediaSequence + len(media.Segments)
+				media.Segments = append(media.Segments, *segment)
+				segment = nil
+			}
+			continue
+		}
+		tag, value, _ := strings.Cut(line, ":")
+		switch tag {
+		case "#EXT-X-STREAM-INF":
+			isMaster = true
+			variant = &HlsVariant{}
+			for key, attribute := range parseHlsAttributes(value) {
+				switch key {
+				case "BANDWIDTH":
+					variant.Bandwidth, _ = strconv.Atoi(attribute)
+				case "AVERAGE-BANDWIDTH":
+					variant.AverageBandwidth, _ = strconv.Atoi(attribute)
+				case "RESOLUTION":
+					variant.Resolution = attribute
+				case "CODECS":
+					variant.Codecs = attribute
+				case "FRAME-RATE":
+					variant.FrameRate, _ = strconv.ParseFloat(attribute, 64)
+				}
+			}
+		case "#EXTINF":
+			durationValue, title, _ := strings.Cut(value, ",")
+			duration, err := strconv.ParseFloat(durationValue, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad #EXTINF %q", ErrInvalidHlsPlaylist, value)
+			}
+			if segment == nil {
+				segment = &HlsSegment{}
+			}
+			segment.Duration = duration
+			segment.Title = title
+		case "#EXT-X-DISCONTINUITY":
+			if segment == nil {
+				segment = &HlsSegment{}
+			}
+			segment.Discontinuity = true
+		case "#EXT-X-VERSION":
+			media.Version, _ = strconv.Atoi(value)
+		case "#EXT-X-TARGETDURATION":
+			media.TargetDuration, _ = strconv.Atoi(value)
+		case "#EXT-X-MEDIA-SEQUENCE":
+			media.MediaSequence, _ = strconv.Atoi(value)
+		case "#EXT-X-DISCONTINUITY-SEQUENCE":
+			media.DiscontinuitySequence, _ = strconv.Atoi(value)
+		case "#EXT-X-PLAYLIST-TYPE":
+			media.PlaylistType = value
+		case "#EXT-X-ENDLIST":
+			media.EndList = true
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if isMaster {
+		return &HlsPlaylist{Master: master}, nil
+	}
+	return &HlsPlaylist{Media: media}, nil
+}
+
+// parseHlsAttributes parses an attribute list such as `BANDWIDTH=1000,CODECS="avc1,mp4a"`.
+func parseHlsAttributes(value string) map[string]string {
+	attributes := map[string]string{}
+	for value != "" {
+		key, rest, found := strings.Cut(value, "=")
+		if !found {
+			break
+		}
+		var attribute string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				attribute, rest = rest[1:], ""
+			} else {
+				attribute, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			attribute, rest, _ = strings.Cut(rest, ",")
+		}
+		attributes[strings.TrimSpace(key)] = attribute
+		value = rest
+	}
+	return attributes
+}
+
+type HlsService ServiceBase
+
+// GetHlsPlaylist fetches and parses the playlist at hlsUrl, e.g. Movie.HlsUrl.
+func (hlsService *HlsService) GetHlsPlaylist(hlsUrl string) (*HlsPlaylist, error) {
+	logger := *hlsService.Logger
+	response, err := hlsService.Client.getUrl(hlsUrl)
+	if err != nil {
+		logger.Error("request failed for GetHlsPlaylist", err)
+		return nil, err
+	}
+	defer hlsService.Client.BodyClose(response.Body)
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("unexpected status code %v for %v", response.StatusCode, hlsUrl)
+		logger.Error("error response for GetHlsPlaylist", err)
+		return nil, err
+	}
+	playlist, err := ParseHlsPlaylist(response.Body, response.Request.URL.String())
+	if err != nil {
+		logger.Error("parse playlist failed for GetHlsPlaylist", err)
+		return nil, err
+	}
+	logger.Debug("response for GetHlsPlaylist", playlist)
+	return playlist, nil
+}
+
+// GetHlsMediaPlaylist fetches the media playlist at hlsUrl.
+// If hlsUrl points to a master playlist, the variant with the highest bandwidth is used.
+func (hlsService *HlsService) GetHlsMediaPlaylist(hlsUrl string) (*HlsMediaPlaylist, error) {
+	playlist, err := hlsService.GetHlsPlaylist(hlsUrl)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Media != nil {
+		return playlist.Media, nil
+	}
+	variant := playlist.Master.BestVariant()
+	if variant == nil {
+		return nil, fmt.Errorf("%w: master playlist has no variants", ErrInvalidHlsPlaylist)
+	}
+	playlist, err = hlsService.GetHlsPlaylist(variant.Url)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Media == nil {
+		return nil, fmt.Errorf("%w: variant is not a media playlist", ErrInvalidHlsPlaylist)
+	}
+	return playlist.Media, nil
+}
+
+// GetHlsSegment downloads a single media segment.
+func (hlsService *HlsService) GetHlsSegment(segmentUrl string) ([]byte, error) {
+	logger := *hlsService.Logger
+	response, err := hlsService.Client.getUrl(segmentUrl)
+	if err != nil {
+		logger.Error("request failed for GetHlsSegment", err)
+		return nil, err
+	}
+	defer hlsService.Client.BodyClose(response.Body)
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("unexpected status code %v for %v", response.StatusCode, segmentUrl)
+		logger.Error("error response for GetHlsSegment", err)
+		return nil, err
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		logger.Error("read response body failed for GetHlsSegment", err)
+		return nil, err
+	}
+	return data, nil
+}
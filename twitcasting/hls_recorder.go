@@ -0,0 +1,280 @@
+package twitcasting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrNotLive = errors.New("user is not live")
+
+type HlsRecordMode int
+
+const (
+	// HlsRecordSingleFile appends every segment to <OutputDir>/<movie id>.ts.
+	HlsRecordSingleFile HlsRecordMode = iota
+	// HlsRecordSegmentDirectory writes each segment to <OutputDir>/<movie id>/<sequence>.ts.
+	HlsRecordSegmentDirectory
+)
+
+type HlsRecordedSegment struct {
+	Sequence      int       `json:"sequence"`
+	Url           string    `json:"url"`
+	Duration      float64   `json:"duration"`
+	Discontinuity bool      `json:"discontinuity"`
+	File          string    `json:"file"`
+	Offset        int64     `json:"offset"`
+	Size          int64     `json:"size"`
+	RecordedAt    time.Time `json:"recorded_at"`
+}
+
+// HlsRecordManifest is written next to the recording as <OutputDir>/<movie id>.json.
+type HlsRecordManifest struct {
+	MovieId   string               `json:"movie_id"`
+	UserId    string               `json:"user_id"`
+	Title     string               `json:"title"`
+	Started   time.Time            `json:"started"`
+	Completed bool                 `json:"completed"`
+	Segments  []HlsRecordedSegment `json:"segments"`
+}
+
+// HlsRecorder records the current live of UserId until it ends.
+type HlsRecorder struct {
+	MovieService   *MovieService
+	HlsService     *HlsService
+	UserId         string
+	OutputDir      string
+	Mode           HlsRecordMode
+	Concurrency    int
+	PollInterval   time.Duration // defaults to the playlist target duration
+	RetryInterval  time.Duration
+	MaxRetries     int // consecutive failures before Record gives up
+	UseBearerToken bool
+}
+
+func CreateHlsRecorder(movieService *MovieService, hlsService *HlsService, userId string, outputDir string) *HlsRecorder {
+	return &HlsRecorder{
+		MovieService:  movieService,
+		HlsService:    hlsService,
+		UserId:        userId,
+		OutputDir:     outputDir,
+		Concurrency:   3,
+		RetryInterval: 2 * time.Second,
+		MaxRetries:    10,
+	}
+}
+
+// Record follows the live media playlist and writes new segments in order until the live ends.
+// An existing manifest for the same movie is resumed. ErrNotLive is returned when the user is not live.
+func (hlsRecorder *HlsRecorder) Record(ctx context.Context) (*HlsRecordManifest, error) {
+	logger := *hlsRecorder.MovieService.Logger
+	container, errorResponse, err := hlsRecorder.MovieService.GetCurrentLive(hlsRecorder.UserId, hlsRecorder.UseBearerToken)
+	if isNotFound(errorResponse) || (err == nil && !container.Movie.IsLive) {
+		return nil, ErrNotLive
+	}
+	if err != nil {
+		return nil, err
+	}
+	movie := container.Movie
+	if err = os.MkdirAll(hlsRecorder.OutputDir, 0o755); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(hlsRecorder.OutputDir, movie.Id+".json")
+	manifest, err := loadHlsRecordManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		manifest = &HlsRecordManifest{MovieId: movie.Id, UserId: hlsRecorder.UserId, Title: movie.Title, Started: time.Now()}
+	}
+	writer, err := hlsRecorder.openWriter(movie.Id, manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.close()
+
+	lastSequence := -1
+	if len(manifest.Segments) > 0 {
+		lastSequence = manifest.Segments[len(manifest.Segments)-1].Sequence
+	}
+	failures := 0
+	for {
+		pollInterval := hlsRecorder.PollInterval
+		playlist, err := hlsRecorder.HlsService.GetHlsMediaPlaylist(movie.HlsUrl)
+		written := 0
+		if err == nil {
+			if pollInterval == 0 {
+				pollInterval = time.Duration(max(playlist.TargetDuration, 1)) * time.Second
+			}
+			var segments []HlsSegment
+			for _, segment := range playlist.Segments {
+				if segment.Sequence > lastSequence {
+					segments = append(segments, segment)
+				}
+			}
+			if lastSequence >= 0 && len(segments) > 0 && segments[0].Sequence > lastSequence+1 {
+				logger.Warn("segments were skipped by HlsRecorder", lastSequence+1, segments[0].Sequence-1)
+				segments[0].Discontinuity = true
+			}
+			written, err = hlsRecorder.writeSegments(ctx, segments, writer, manifest)
+			if written > 0 {
+				lastSequence = manifest.Segments[len(manifest.Segments)-1].Sequence
+				if saveErr := saveHlsRecordManifest(manifestPath, manifest); saveErr != nil {
+					return manifest, saveErr
+				}
+			}
+			if err == nil && playlist.EndList {
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			return manifest, ctx.Err()
+		}
+		if err != nil {
+			failures++
+			logger.Warn("recording failed for HlsRecorder", failures, err)
+			if failures > hlsRecorder.MaxRetries {
+				return manifest, err
+			}
+			pollInterval = hlsRecorder.RetryInterval
+		} else {
+			failures = 0
+		}
+		if written == 0 && !hlsRecorder.isStillLive(movie.Id) {
+			break
+		}
+		if err = sleepContext(ctx, pollInterval); err != nil {
+			return manifest, err
+		}
+	}
+	manifest.Completed = true
+	return manifest, saveHlsRecordManifest(manifestPath, manifest)
+}
+
+// writeSegments downloads segments concurrently and writes them in order.
+// It stops at the first segment that could not be downloaded so it is retried on the next poll.
+func (hlsRecorder *HlsRecorder) writeSegments(ctx context.Context, segments []HlsSegment, writer *hlsSegmentWriter, manifest *HlsRecordManifest) (int, error) {
+	data := make([][]byte, len(segments))
+	errs := make([]error, len(segments))
+	runConcurrently(ctx, len(segments), hlsRecorder.Concurrency, func(i int) {
+		data[i], errs[i] = hlsRecorder.HlsService.GetHlsSegment(segments[i].Url)
+	})
+	for i, segment := range segments {
+		if errs[i] != nil {
+			return i, errs[i]
+		}
+		if data[i] == nil {
+			return i, ctx.Err()
+		}
+		recorded, err := writer.write(segment, data[i])
+		if err != nil {
+			return i, err
+		}
+		manifest.Segments = append(manifest.Segments, recorded)
+	}
+	return len(segments), nil
+}
+
+func (hlsRecorder *HlsRecorder) isStillLive(movieId string) bool {
+	container, errorResponse, err := hlsRecorder.MovieService.GetCurrentLive(hlsRecorder.UserId, hlsRecorder.UseBearerToken)
+	if isNotFound(errorResponse) {
+		return false
+	}
+	if err != nil {
+		// the live status is unknown, keep recording
+		return true
+	}
+	return container.Movie.IsLive && container.Movie.Id == movieId
+}
+
+type hlsSegmentWriter struct {
+	mode   HlsRecordMode
+	dir    string
+	file   *os.File
+	offset int64
+}
+
+func (hlsRecorder *HlsRecorder) openWriter(movieId string, manifest *HlsRecordManifest) (*hlsSegmentWriter, error) {
+	if hlsRecorder.Mode == HlsRecordSegmentDirectory {
+		dir := filepath.Join(hlsRecorder.OutputDir, movieId)
+		return &hlsSegmentWriter{mode: hlsRecorder.Mode, dir: dir}, os.MkdirAll(dir, 0o755)
+	}
+	file, err := os.OpenFile(filepath.Join(hlsRecorder.OutputDir, movieId+".ts"), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// drop bytes of a segment that was written before an interruption but not added to the manifest
+	var offset int64
+	if len(manifest.Segments) > 0 {
+		last := manifest.Segments[len(manifest.Segments)-1]
+		offset = last.Offset + last.Size
+	}
+	if err = file.Truncate(offset); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if _, err = file.Seek(offset, 0); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &hlsSegmentWriter{mode: hlsRecorder.Mode, file: file, offset: offset}, nil
+}
+
+func (writer *hlsSegmentWriter) write(segment HlsSegment, data []byte) (HlsRecordedSegment, error) {
+	recorded := HlsRecordedSegment{
+		Sequence:      segment.Sequence,
+		Url:           segment.Url,
+		Duration:      segment.Duration,
+		Discontinuity: segment.Discontinuity,
+		Size:          int64(len(data)),
+		RecordedAt:    time.Now(),
+	}
+	if writer.mode == HlsRecordSegmentDirectory {
+		recorded.File = fmt.Sprintf("%d.ts", segment.Sequence)
+		return recorded, os.WriteFile(filepath.Join(writer.dir, recorded.File), data, 0o644)
+	}
+	recorded.File = filepath.Base(writer.file.Name())
+	recorded.Offset = writer.offset
+	if _, err := writer.file.Write(data); err != nil {
+		return recorded, err
+	}
+	writer.offset += recorded.Size
+	return recorded, nil
+}
+
+func (writer *hlsSegmentWriter) close() {
+	if writer.file != nil {
+		_ = writer.file.Close()
+	}
+}
+
+func loadHlsRecordManifest(path string) (*HlsRecordManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(HlsRecordManifest)
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func saveHlsRecordManifest(path string, manifest *HlsRecordManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err = os.WriteFile(temporaryPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
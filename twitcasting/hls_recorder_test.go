@@ -0,0 +1,157 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeLiveHlsServer struct {
+	mu       sync.Mutex
+	url      string
+	isLive   bool
+	sequence int // first sequence in the playlist window
+	count    int // segments currently in the playlist
+	total    int // segments produced before the live ends
+	endList  bool
+	failOnce map[int]bool
+}
+
+func (fake *fakeLiveHlsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/current_live"):
+		if !fake.isLive {
+			w.WriteHeader(http.StatusNotFound)
+			body, _ := json.Marshal(twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 404, Message: "Not Found"}})
+			_, _ = w.Write(body)
+			return
+		}
+		body, _ := json.Marshal(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "100", Title: "title", IsLive: true, HlsUrl: fake.url + "/hls/index.m3u8"}})
+		_, _ = w.Write(body)
+	case r.URL.Path == "/hls/index.m3u8":
+		// every poll a new segment appears and the window slides by one
+		if fake.sequence+fake.count < fake.total {
+			fake.count++
+			if fake.count > 3 {
+				fake.sequence++
+				fake.count = 3
+			}
+		} else if !fake.endList {
+			fake.isLive = false
+		}
+		_, _ = fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:%d\n", fake.sequence)
+		for i := fake.sequence; i < fake.sequence+fake.count; i++ {
+			_, _ = fmt.Fprintf(w, "#EXTINF:1.0,\n%d.ts\n", i)
+		}
+		if fake.endList && fake.sequence+fake.count == fake.total {
+			_, _ = fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+		}
+	default:
+		var sequence int
+		_, _ = fmt.Sscanf(r.URL.Path, "/hls/%d.ts", &sequence)
+		if fake.failOnce[sequence] {
+			delete(fake.failOnce, sequence)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "[%d]", sequence)
+	}
+}
+
+func createTestHlsRecorder(fake *fakeLiveHlsServer, outputDir string) (*twitcasting.HlsRecorder, func()) {
+	server := httptest.NewServer(fake)
+	fake.url = server.URL
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	recorder := twitcasting.CreateHlsRecorder(locator.Movie, locator.Hls, "user", outputDir)
+	recorder.PollInterval = time.Millisecond
+	recorder.RetryInterval = time.Millisecond
+	return recorder, server.Close
+}
+
+func TestHlsRecorderSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeLiveHlsServer{isLive: true, total: 6, failOnce: map[int]bool{3: true}}
+	recorder, closeServer := createTestHlsRecorder(fake, dir)
+	defer closeServer()
+
+	manifest, err := recorder.Record(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, manifest.Completed)
+	assert.Equal(t, 6, len(manifest.Segments))
+	assert.Equal(t, int64(9), manifest.Segments[3].Offset)
+	data, _ := os.ReadFile(filepath.Join(dir, "100.ts"))
+	assert.Equal(t, "[0][1][2][3][4][5]", string(data))
+
+	saved, _ := os.ReadFile(filepath.Join(dir, "100.json"))
+	savedManifest := twitcasting.HlsRecordManifest{}
+	assert.Nil(t, json.Unmarshal(saved, &savedManifest))
+	assert.Equal(t, "100", savedManifest.MovieId)
+	assert.True(t, savedManifest.Completed)
+	assert.Equal(t, 6, len(savedManifest.Segments))
+
+	_, err = recorder.Record(context.Background())
+	assert.ErrorIs(t, err, twitcasting.ErrNotLive)
+}
+
+func TestHlsRecorderResume(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeLiveHlsServer{isLive: true, sequence: 2, total: 5}
+	recorder, closeServer := createTestHlsRecorder(fake, dir)
+	defer closeServer()
+	manifest := twitcasting.HlsRecordManifest{MovieId: "100", Segments: []twitcasting.HlsRecordedSegment{
+		{Sequence: 0, File: "100.ts", Offset: 0, Size: 3},
+		{Sequence: 1, File: "100.ts", Offset: 3, Size: 3},
+	}}
+	data, _ := json.Marshal(manifest)
+	_ = os.WriteFile(filepath.Join(dir, "100.json"), data, 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "100.ts"), []byte("[0][1][2"), 0o644)
+
+	result, err := recorder.Record(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(result.Segments))
+	written, _ := os.ReadFile(filepath.Join(dir, "100.ts"))
+	assert.Equal(t, "[0][1][2][3][4]", string(written))
+}
+
+func TestHlsRecorderSegmentDirectory(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeLiveHlsServer{isLive: true, total: 4, endList: true}
+	recorder, closeServer := createTestHlsRecorder(fake, dir)
+	defer closeServer()
+	recorder.Mode = twitcasting.HlsRecordSegmentDirectory
+
+	manifest, err := recorder.Record(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, manifest.Completed)
+	assert.Equal(t, 4, len(manifest.Segments))
+	data, _ := os.ReadFile(filepath.Join(dir, "100", "3.ts"))
+	assert.Equal(t, "[3]", string(data))
+	assert.Equal(t, "3.ts", manifest.Segments[3].File)
+}
+
+func TestHlsRecorderCancel(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeLiveHlsServer{isLive: true, total: 1000}
+	recorder, closeServer := createTestHlsRecorder(fake, dir)
+	defer closeServer()
+	recorder.PollInterval = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	manifest, err := recorder.Record(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, manifest.Completed)
+	assert.Equal(t, 1, len(manifest.Segments))
+}
@@ -0,0 +1,80 @@
+package twitcasting_test
+
+import (
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func CreateTestHlsServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/metastream.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/master.m3u8")
+	})
+	mux.HandleFunc("/user/high/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/media_live.m3u8")
+	})
+	mux.HandleFunc("/archive.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/media_archive.m3u8")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGetHlsPlaylist(t *testing.T) {
+	server := CreateTestHlsServer()
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	playlist, err := locator.Hls.GetHlsPlaylist(server.URL + "/user/metastream.m3u8")
+	assert.Nil(t, err)
+	assert.Nil(t, playlist.Media)
+	assert.Equal(t, []twitcasting.HlsVariant{
+		{Url: server.URL + "/user/low/index.m3u8", Bandwidth: 500000, Resolution: "640x360", Codecs: "avc1.42e01e,mp4a.40.2"},
+		{Url: server.URL + "/user/high/index.m3u8", Bandwidth: 2000000, AverageBandwidth: 1800000, Resolution: "1280x720", Codecs: "avc1.64001f,mp4a.40.2", FrameRate: 30},
+	}, playlist.Master.Variants)
+	assert.Equal(t, server.URL+"/user/high/index.m3u8", playlist.Master.BestVariant().Url)
+
+	playlist, err = locator.Hls.GetHlsPlaylist(server.URL + "/archive.m3u8")
+	assert.Nil(t, err)
+	assert.Nil(t, playlist.Master)
+	assert.True(t, playlist.Media.EndList)
+	assert.Equal(t, "VOD", playlist.Media.PlaylistType)
+	assert.Equal(t, 4, playlist.Media.TargetDuration)
+	assert.Equal(t, 7.25, playlist.Media.Duration())
+	assert.Equal(t, "https://cdn.example.com/archive/1.ts", playlist.Media.Segments[1].Url)
+
+	playlist, err = locator.Hls.GetHlsPlaylist(server.URL + "/missing.m3u8")
+	assert.Nil(t, playlist)
+	assert.NotNil(t, err)
+}
+
+func TestGetHlsMediaPlaylist(t *testing.T) {
+	server := CreateTestHlsServer()
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	media, err := locator.Hls.GetHlsMediaPlaylist(server.URL + "/user/metastream.m3u8")
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/user/high/index.m3u8", media.Url)
+	assert.Equal(t, 3, media.Version)
+	assert.Equal(t, 120, media.MediaSequence)
+	assert.False(t, media.EndList)
+	assert.Equal(t, []twitcasting.HlsSegment{
+		{Url: server.URL + "/user/high/segment120.ts", Sequence: 120, Duration: 2},
+		{Url: server.URL + "/user/high/segment121.ts", Sequence: 121, Duration: 2},
+		{Url: server.URL + "/user/high/segment122.ts", Sequence: 122, Duration: 1.5, Title: "live", Discontinuity: true},
+	}, media.Segments)
+}
+
+func TestParseHlsPlaylistInvalid(t *testing.T) {
+	playlist, err := twitcasting.ParseHlsPlaylist(strings.NewReader("<html></html>"), "https://example.com/")
+	assert.Nil(t, playlist)
+	assert.ErrorIs(t, err, twitcasting.ErrInvalidHlsPlaylist)
+
+	playlist, err = twitcasting.ParseHlsPlaylist(strings.NewReader("#EXTM3U\n#EXTINF:abc,\n0.ts\n"), "https://example.com/")
+	assert.Nil(t, playlist)
+	assert.ErrorIs(t, err, twitcasting.ErrInvalidHlsPlaylist)
+}
@@ -0,0 +1,258 @@
+package twitcasting
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ErrInvalidRequest is wrapped by the errors of requests rejected before being sent.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// SupporterSort orders GetSupporterList. An empty SupporterSort is sent as the API default, ranking.
+type SupporterSort string
+
+const (
+	SupporterSortNew     SupporterSort = "new"
+	SupporterSortRanking SupporterSort = "ranking"
+)
+
+// GetCommentsRequest lists the comments of MovieId from Offset, or after SliceId when it is set.
+type GetCommentsRequest struct {
+	MovieId        string
+	Limit          int
+	Offset         int
+	SliceId        string
+	UseBearerToken bool
+}
+
+func (request GetCommentsRequest) Validate() error {
+	if err := validateRequired("GetComments", "movie_id", request.MovieId); err != nil {
+		return err
+	}
+	if err := validateRange("GetComments", "limit", request.Limit, 1, 50); err != nil {
+		return err
+	}
+	if request.SliceId != "" && request.Offset != 0 {
+		return invalidRequest("GetComments", "offset and slice_id cannot be used together")
+	}
+	return validateRange("GetComments", "offset", request.Offset, 0, -1)
+}
+
+func (request GetCommentsRequest) query() url.Values {
+	query := url.Values{"limit": {strconv.Itoa(request.Limit)}}
+	if request.SliceId != "" {
+		query.Set("slice_id", request.SliceId)
+	} else {
+		query.Set("offset", strconv.Itoa(request.Offset))
+	}
+	return query
+}
+
+// GetUserMoviesRequest lists the movies of UserId from Offset, or after SliceId when it is set.
+type GetUserMoviesRequest struct {
+	UserId         string
+	Limit          int
+	Offset         int
+	SliceId        string
+	UseBearerToken bool
+}
+
+func (request GetUserMoviesRequest) Validate() error {
+	if err := validateRequired("GetUserMovies", "user_id", request.UserId); err != nil {
+		return err
+	}
+	if err := validateRange("GetUserMovies", "limit", request.Limit, 1, 50); err != nil {
+		return err
+	}
+	if request.SliceId != "" && request.Offset != 0 {
+		return invalidRequest("GetUserMovies", "offset and slice_id cannot be used together")
+	}
+	return validateRange("GetUserMovies", "offset", request.Offset, 0, 1000)
+}
+
+func (request GetUserMoviesRequest) query() url.Values {
+	query := url.Values{"limit": {strconv.Itoa(request.Limit)}}
+	if request.SliceId != "" {
+		query.Set("slice_id", request.SliceId)
+	} else {
+		query.Set("offset", strconv.Itoa(request.Offset))
+	}
+	return query
+}
+
+type GetSupportingListRequest struct {
+	UserId         string
+	Limit          int
+	Offset         int
+	UseBearerToken bool
+}
+
+func (request GetSupportingListRequest) Validate() error {
+	if err := validateRequired("GetSupportingList", "user_id", request.UserId); err != nil {
+		return err
+	}
+	if err := validateRange("GetSupportingList", "limit", request.Limit, 1, supporterListPageLimit); err != nil {
+		return err
+	}
+	return validateRange("GetSupportingList", "offset", request.Offset, 0, -1)
+}
+
+func (request GetSupportingListRequest) query() url.Values {
+	return url.Values{
+		"limit":  {strconv.Itoa(request.Limit)},
+		"offset": {strconv.Itoa(request.Offset)},
+	}
+}
+
+type GetSupporterListRequest struct {
+	UserId         string
+	Limit          int
+	Offset         int
+	Sort           SupporterSort
+	UseBearerToken bool
+}
+
+func (request GetSupporterListRequest) Validate() error {
+	if err := validateRequired("GetSupporterList", "user_id", request.UserId); err != nil {
+		return err
+	}
+	if err := validateRange("GetSupporterList", "limit", request.Limit, 1, supporterListPageLimit); err != nil {
+		return err
+	}
+	if err := validateRange("GetSupporterList", "offset", request.Offset, 0, -1); err != nil {
+		return err
+	}
+	if request.Sort != "" && request.Sort != SupporterSortNew && request.Sort != SupporterSortRanking {
+		return invalidRequest("GetSupporterList", fmt.Sprintf("sort must be %q or %q, got %q", SupporterSortNew, SupporterSortRanking, request.Sort))
+	}
+	return nil
+}
+
+func (request GetSupporterListRequest) query() url.Values {
+	sort := request.Sort
+	if sort == "" {
+		sort = SupporterSortRanking
+	}
+	return url.Values{
+		"limit":  {strconv.Itoa(request.Limit)},
+		"offset": {strconv.Itoa(request.Offset)},
+		"sort":   {string(sort)},
+	}
+}
+
+// GetGiftsRequest lists the gifts after SliceId, or the latest ones when it is empty.
+// It is always sent with the Bearer Token.
+type GetGiftsRequest struct {
+	SliceId string
+}
+
+func (request GetGiftsRequest) Validate() error {
+	if request.SliceId == "" {
+		return nil
+	}
+	sliceId, err := strconv.Atoi(request.SliceId)
+	if err != nil {
+		return invalidRequest("GetGifts", fmt.Sprintf("slice_id must be a number, got %q", request.SliceId))
+	}
+	return validateRange("GetGifts", "slice_id", sliceId, -1, -1)
+}
+
+func (request GetGiftsRequest) query() url.Values {
+	query := url.Values{}
+	if request.SliceId != "" {
+		query.Set("slice_id", request.SliceId)
+	}
+	return query
+}
+
+// GetWebhookListRequest is always sent with the Basic Token.
+type GetWebhookListRequest struct {
+	Limit  int
+	Offset int
+}
+
+func (request GetWebhookListRequest) Validate() error {
+	if err := validateRange("GetWebhookList", "limit", request.Limit, 1, 50); err != nil {
+		return err
+	}
+	return validateRange("GetWebhookList", "offset", request.Offset, 0, -1)
+}
+
+func (request GetWebhookListRequest) query() url.Values {
+	return url.Values{
+		"limit":  {strconv.Itoa(request.Limit)},
+		"offset": {strconv.Itoa(request.Offset)},
+	}
+}
+
+type SearchUsersRequest struct {
+	Words          string
+	Limit          int
+	UseBearerToken bool
+}
+
+func (request SearchUsersRequest) Validate() error {
+	if err := validateRequired("SearchUsers", "words", request.Words); err != nil {
+		return err
+	}
+	return validateRange("SearchUsers", "limit", request.Limit, 1, 50)
+}
+
+func (request SearchUsersRequest) query() url.Values {
+	return url.Values{
+		"words": {request.Words},
+		"limit": {strconv.Itoa(request.Limit)},
+		"lang":  {"ja"},
+	}
+}
+
+// SearchLiveMoviesRequest searches by Type ("tag", "word", "category", "new" or "recommend") and Context.
+type SearchLiveMoviesRequest struct {
+	Type           string
+	Context        string
+	Limit          int
+	UseBearerToken bool
+}
+
+func (request SearchLiveMoviesRequest) Validate() error {
+	if err := validateRequired("SearchLiveMovies", "type", request.Type); err != nil {
+		return err
+	}
+	return validateRange("SearchLiveMovies", "limit", request.Limit, 1, 100)
+}
+
+func (request SearchLiveMoviesRequest) query() url.Values {
+	return url.Values{
+		"type":    {request.Type},
+		"context": {request.Context},
+		"limit":   {strconv.Itoa(request.Limit)},
+		"lang":    {"ja"},
+	}
+}
+
+func invalidRequest(endpoint string, message string) error {
+	return fmt.Errorf("%w for %s: %s", ErrInvalidRequest, endpoint, message)
+}
+
+func validateRequired(endpoint string, name string, value string) error {
+	if value == "" {
+		return invalidRequest(endpoint, name+" is required")
+	}
+	return nil
+}
+
+// validateRange checks lower <= value <= upper. A negative upper leaves the range open.
+func validateRange(endpoint string, name string, value int, lower int, upper int) error {
+	if value < lower {
+		if upper < 0 {
+			return invalidRequest(endpoint, fmt.Sprintf("%s must be at least %d, got %d", name, lower, value))
+		}
+		return invalidRequest(endpoint, fmt.Sprintf("%s must be between %d and %d, got %d", name, lower, upper, value))
+	}
+	if upper >= 0 && value > upper {
+		return invalidRequest(endpoint, fmt.Sprintf("%s must be between %d and %d, got %d", name, lower, upper, value))
+	}
+	return nil
+}
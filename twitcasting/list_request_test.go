@@ -0,0 +1,127 @@
+package twitcasting_test
+
+import (
+	"errors"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestListRequestValidate(t *testing.T) {
+	tests := []struct {
+		request interface{ Validate() error }
+		message string
+	}{
+		{twitcasting.GetCommentsRequest{MovieId: "1", Limit: 50}, ""},
+		{twitcasting.GetCommentsRequest{MovieId: "1", Limit: 0}, "invalid request for GetComments: limit must be between 1 and 50, got 0"},
+		{twitcasting.GetCommentsRequest{MovieId: "1", Limit: 51}, "invalid request for GetComments: limit must be between 1 and 50, got 51"},
+		{twitcasting.GetCommentsRequest{MovieId: "1", Limit: 10, Offset: -1}, "invalid request for GetComments: offset must be at least 0, got -1"},
+		{twitcasting.GetCommentsRequest{MovieId: "1", Limit: 10, Offset: 10, SliceId: "5"}, "invalid request for GetComments: offset and slice_id cannot be used together"},
+		{twitcasting.GetCommentsRequest{Limit: 10}, "invalid request for GetComments: movie_id is required"},
+		{twitcasting.GetUserMoviesRequest{UserId: "1", Limit: 20, Offset: 1000}, ""},
+		{twitcasting.GetUserMoviesRequest{UserId: "1", Limit: 20, Offset: 1001}, "invalid request for GetUserMovies: offset must be between 0 and 1000, got 1001"},
+		{twitcasting.GetSupportingListRequest{UserId: "1", Limit: 20}, ""},
+		{twitcasting.GetSupportingListRequest{UserId: "1", Limit: 21}, "invalid request for GetSupportingList: limit must be between 1 and 20, got 21"},
+		{twitcasting.GetSupporterListRequest{UserId: "1", Limit: 20, Sort: twitcasting.SupporterSortRanking}, ""},
+		{twitcasting.GetSupporterListRequest{UserId: "1", Limit: 20, Sort: "old"}, `invalid request for GetSupporterList: sort must be "new" or "ranking", got "old"`},
+		{twitcasting.GetSupporterListRequest{UserId: "1", Limit: 20}, ""},
+		{twitcasting.GetGiftsRequest{}, ""},
+		{twitcasting.GetGiftsRequest{SliceId: "-1"}, ""},
+		{twitcasting.GetGiftsRequest{SliceId: "abc"}, `invalid request for GetGifts: slice_id must be a number, got "abc"`},
+		{twitcasting.GetGiftsRequest{SliceId: "-2"}, "invalid request for GetGifts: slice_id must be at least -1, got -2"},
+		{twitcasting.GetWebhookListRequest{Limit: 50}, ""},
+		{twitcasting.GetWebhookListRequest{Limit: 100}, "invalid request for GetWebhookList: limit must be between 1 and 50, got 100"},
+		{twitcasting.SearchUsersRequest{Words: "test", Limit: 51}, "invalid request for SearchUsers: limit must be between 1 and 50, got 51"},
+		{twitcasting.SearchUsersRequest{Limit: 10}, "invalid request for SearchUsers: words is required"},
+		{twitcasting.SearchLiveMoviesRequest{Type: "recommend", Limit: 100}, ""},
+		{twitcasting.SearchLiveMoviesRequest{Type: "recommend", Limit: 101}, "invalid request for SearchLiveMovies: limit must be between 1 and 100, got 101"},
+	}
+	for _, test := range tests {
+		err := test.request.Validate()
+		if test.message == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		if assert.NotNil(t, err) {
+			assert.Equal(t, test.message, err.Error())
+			assert.True(t, errors.Is(err, twitcasting.ErrInvalidRequest))
+		}
+	}
+}
+
+func TestGetSupporterListWith(t *testing.T) {
+	expected := twitcasting.SupporterListContainer{
+		Total:      1,
+		Supporters: []twitcasting.SupporterUser{{Id: "3456543", ScreenId: "screen_id", Point: 10, TotalPoint: 20}},
+	}
+	server := CreateTestSever(t, expected, "", url.Values{"limit": []string{"20"}, "offset": []string{"40"}, "sort": []string{"ranking"}}, true, http.StatusOK)
+	server.Start()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	supporterListResponse, errorResponse, err := locator.Supporter.GetSupporterListWith(twitcasting.GetSupporterListRequest{
+		UserId:         "3456543",
+		Limit:          20,
+		Offset:         40,
+		Sort:           twitcasting.SupporterSortRanking,
+		UseBearerToken: true,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, &expected, supporterListResponse)
+	server.Close()
+}
+
+func TestGetSupporterListDefaultSort(t *testing.T) {
+	expected := twitcasting.SupporterListContainer{
+		Total:      1,
+		Supporters: []twitcasting.SupporterUser{{Id: "3456543", ScreenId: "screen_id", Point: 10, TotalPoint: 20}},
+	}
+	server := CreateTestSever(t, expected, "", url.Values{"limit": []string{"20"}, "offset": []string{"0"}, "sort": []string{"ranking"}}, false, http.StatusOK)
+	server.Start()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	supporterListResponse, errorResponse, err := locator.Supporter.GetSupporterList("3456543", 20, 0, "", false)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, &expected, supporterListResponse)
+	server.Close()
+}
+
+func TestGetCommentsWithSliceId(t *testing.T) {
+	expected := twitcasting.CommentListContainer{MovieId: "1456543", AllCount: 0, Comments: []twitcasting.Comment{}}
+	server := CreateTestSever(t, expected, "", url.Values{"limit": []string{"50"}, "slice_id": []string{"10000"}}, false, http.StatusOK)
+	server.Start()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	commentsResponse, errorResponse, err := locator.Comment.GetCommentsWith(twitcasting.GetCommentsRequest{MovieId: "1456543", Limit: 50, SliceId: "10000"})
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, &expected, commentsResponse)
+	server.Close()
+}
+
+func TestInvalidListRequestIsNotSent(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	supporterListResponse, errorResponse, err := locator.Supporter.GetSupporterList("3456543", 30, 0, "new", false)
+	assert.Nil(t, supporterListResponse)
+	assert.Nil(t, errorResponse)
+	assert.True(t, errors.Is(err, twitcasting.ErrInvalidRequest))
+
+	moviesResponse, errorResponse, err := locator.Movie.GetUserMoviesBySliceId("123454", 0, "12345432", false)
+	assert.Nil(t, moviesResponse)
+	assert.Nil(t, errorResponse)
+	assert.True(t, errors.Is(err, twitcasting.ErrInvalidRequest))
+
+	webhookResponse, errorResponse, err := locator.Webhook.GetWebhookList(10, -1)
+	assert.Nil(t, webhookResponse)
+	assert.Nil(t, errorResponse)
+	assert.True(t, errors.Is(err, twitcasting.ErrInvalidRequest))
+
+	assert.Equal(t, 0, requests)
+}
@@ -0,0 +1,171 @@
+package twitcasting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type LiveDirectorySort string
+
+const (
+	// LiveDirectorySortLive puts users on air first, ordered by viewers.
+	LiveDirectorySortLive     LiveDirectorySort = "live"
+	LiveDirectorySortViewers  LiveDirectorySort = "viewers"
+	LiveDirectorySortCategory LiveDirectorySort = "category"
+)
+
+type LiveDirectoryEntry struct {
+	User      User            `json:"user"`
+	Live      *MovieContainer `json:"live"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+type LiveDirectoryContainer struct {
+	Entries []LiveDirectoryEntry `json:"entries"`
+}
+
+// LiveDirectory aggregates the users and current lives of a roster.
+// GetCurrentLive is only requested for users reported live by GetUser.
+type LiveDirectory struct {
+	UserService    *UserService
+	MovieService   *MovieService
+	TTL            time.Duration
+	Concurrency    int
+	UseBearerToken bool
+
+	mu      sync.Mutex
+	userIds []string
+	cache   map[string]LiveDirectoryEntry
+}
+
+func CreateLiveDirectory(userService *UserService, movieService *MovieService, userIds []string) *LiveDirectory {
+	return &LiveDirectory{
+		UserService:  userService,
+		MovieService: movieService,
+		TTL:          time.Minute,
+		Concurrency:  4,
+		userIds:      userIds,
+	}
+}
+
+func (liveDirectory *LiveDirectory) SetUserIds(userIds []string) {
+	liveDirectory.mu.Lock()
+	defer liveDirectory.mu.Unlock()
+	liveDirectory.userIds = userIds
+}
+
+// Entries returns the directory sorted by sortBy, refreshing entries older than TTL.
+// Users that cannot be resolved are left out unless an older entry is cached.
+func (liveDirectory *LiveDirectory) Entries(ctx context.Context, sortBy LiveDirectorySort) []LiveDirectoryEntry {
+	liveDirectory.mu.Lock()
+	userIds := liveDirectory.userIds
+	now := time.Now()
+	var stale []string
+	for _, userId := range userIds {
+		entry, ok := liveDirectory.cache[userId]
+		if !ok || now.Sub(entry.FetchedAt) >= liveDirectory.TTL {
+			stale = append(stale, userId)
+		}
+	}
+	liveDirectory.mu.Unlock()
+
+	runConcurrently(ctx, len(stale), liveDirectory.Concurrency, func(i int) {
+		entry, ok := liveDirectory.fetch(stale[i])
+		if !ok {
+			return
+		}
+		liveDirectory.mu.Lock()
+		if liveDirectory.cache == nil {
+			liveDirectory.cache = map[string]LiveDirectoryEntry{}
+		}
+		liveDirectory.cache[stale[i]] = entry
+		liveDirectory.mu.Unlock()
+	})
+
+	liveDirectory.mu.Lock()
+	entries := make([]LiveDirectoryEntry, 0, len(userIds))
+	for _, userId := range userIds {
+		if entry, ok := liveDirectory.cache[userId]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	liveDirectory.mu.Unlock()
+	SortLiveDirectoryEntries(entries, sortBy)
+	return entries
+}
+
+func (liveDirectory *LiveDirectory) fetch(userId string) (LiveDirectoryEntry, bool) {
+	logger := *liveDirectory.UserService.Logger
+	user, _, err := liveDirectory.UserService.GetUser(userId, liveDirectory.UseBearerToken)
+	if err != nil {
+		logger.Warn("get user failed for LiveDirectory", userId, err)
+		return LiveDirectoryEntry{}, false
+	}
+	entry := LiveDirectoryEntry{User: user.User, FetchedAt: time.Now()}
+	if !user.User.IsLive {
+		return entry, true
+	}
+	live, errorResponse, err := liveDirectory.MovieService.GetCurrentLive(userId, liveDirectory.UseBearerToken)
+	if err != nil && !isNotFound(errorResponse) {
+		logger.Warn("get current live failed for LiveDirectory", userId, err)
+		return LiveDirectoryEntry{}, false
+	}
+	if err == nil && live.Movie.IsLive {
+		entry.Live = live
+	} else {
+		entry.User.IsLive = false
+	}
+	return entry, true
+}
+
+// SortLiveDirectoryEntries sorts entries in place.
+func SortLiveDirectoryEntries(entries []LiveDirectoryEntry, sortBy LiveDirectorySort) {
+	viewers := func(entry LiveDirectoryEntry) int {
+		if entry.Live == nil {
+			return -1
+		}
+		return entry.Live.Movie.CurrentViewCount
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch sortBy {
+		case LiveDirectorySortCategory:
+			if (a.Live == nil) != (b.Live == nil) {
+				return a.Live != nil
+			}
+			if a.Live != nil && a.Live.Movie.Category != b.Live.Movie.Category {
+				return a.Live.Movie.Category < b.Live.Movie.Category
+			}
+		case LiveDirectorySortLive:
+			if (a.Live == nil) != (b.Live == nil) {
+				return a.Live != nil
+			}
+		}
+		if viewers(a) != viewers(b) {
+			return viewers(a) > viewers(b)
+		}
+		return a.User.ScreenId < b.User.ScreenId
+	})
+}
+
+// ServeHTTP responds with a LiveDirectoryContainer. The order is chosen with the sort query parameter.
+func (liveDirectory *LiveDirectory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sortBy := LiveDirectorySort(r.URL.Query().Get("sort"))
+	switch sortBy {
+	case LiveDirectorySortLive, LiveDirectorySortViewers, LiveDirectorySortCategory:
+	case "":
+		sortBy = LiveDirectorySortLive
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Error: Error{Code: http.StatusBadRequest, Message: "invalid sort: " + string(sortBy)}})
+		return
+	}
+	entries := liveDirectory.Entries(r.Context(), sortBy)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LiveDirectoryContainer{Entries: entries})
+}
@@ -0,0 +1,92 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeDirectoryServer struct {
+	mu       sync.Mutex
+	lives    map[string]twitcasting.Movie
+	requests int
+}
+
+func (fake *fakeDirectoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests++
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	live, isLive := fake.lives[path[0]]
+	if len(path) == 1 {
+		body, _ := json.Marshal(twitcasting.UserContainer{User: twitcasting.User{Id: path[0], ScreenId: path[0], IsLive: isLive}})
+		_, _ = w.Write(body)
+		return
+	}
+	body, _ := json.Marshal(twitcasting.MovieContainer{Movie: live})
+	_, _ = w.Write(body)
+}
+
+func screenIds(entries []twitcasting.LiveDirectoryEntry) []string {
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.User.ScreenId)
+	}
+	return ids
+}
+
+func TestLiveDirectory(t *testing.T) {
+	fake := &fakeDirectoryServer{lives: map[string]twitcasting.Movie{
+		"a": {Id: "1", IsLive: true, Category: "music", CurrentViewCount: 5},
+		"b": {Id: "2", IsLive: true, Category: "game", CurrentViewCount: 3},
+		"c": {Id: "3", IsLive: true, Category: "music", CurrentViewCount: 20},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	directory := twitcasting.CreateLiveDirectory(locator.User, locator.Movie, []string{"offline", "a", "b", "c"})
+	ctx := context.Background()
+
+	entries := directory.Entries(ctx, twitcasting.LiveDirectorySortLive)
+	assert.Equal(t, []string{"c", "a", "b", "offline"}, screenIds(entries))
+	assert.Nil(t, entries[3].Live)
+	assert.Equal(t, 20, entries[0].Live.Movie.CurrentViewCount)
+	assert.Equal(t, 7, fake.requests)
+
+	assert.Equal(t, []string{"b", "c", "a", "offline"}, screenIds(directory.Entries(ctx, twitcasting.LiveDirectorySortCategory)))
+	assert.Equal(t, 7, fake.requests)
+
+	directory.TTL = 0
+	delete(fake.lives, "c")
+	assert.Equal(t, []string{"a", "b", "c", "offline"}, screenIds(directory.Entries(ctx, twitcasting.LiveDirectorySortViewers)))
+	assert.Equal(t, 13, fake.requests)
+}
+
+func TestLiveDirectoryServeHTTP(t *testing.T) {
+	fake := &fakeDirectoryServer{lives: map[string]twitcasting.Movie{"a": {Id: "1", IsLive: true}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	directory := twitcasting.CreateLiveDirectory(locator.User, locator.Movie, []string{"b", "a"})
+	directory.TTL = time.Hour
+
+	recorder := httptest.NewRecorder()
+	directory.ServeHTTP(recorder, httptest.NewRequest("GET", "/?sort=live", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	container := twitcasting.LiveDirectoryContainer{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &container))
+	assert.Equal(t, []string{"a", "b"}, screenIds(container.Entries))
+	assert.Equal(t, "1", container.Entries[0].Live.Movie.Id)
+
+	recorder = httptest.NewRecorder()
+	directory.ServeHTTP(recorder, httptest.NewRequest("GET", "/?sort=unknown", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,205 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxSubtitleLength = 17
+	MaxHashtagLength  = 26
+)
+
+// ValidateSubtitle checks the length limit of PostCurrentLiveSubtitle.
+func ValidateSubtitle(subtitle string) error {
+	length := utf8.RuneCountInString(subtitle)
+	if length < 1 || length > MaxSubtitleLength {
+		return fmt.Errorf("subtitle must be 1 to %v characters: %q", MaxSubtitleLength, subtitle)
+	}
+	return nil
+}
+
+// ValidateHashtag checks the length limit of PostCurrentLiveHashtag.
+func ValidateHashtag(hashtag string) error {
+	length := utf8.RuneCountInString(hashtag)
+	if length < 1 || length > MaxHashtagLength {
+		return fmt.Errorf("hashtag must be 1 to %v characters: %q", MaxHashtagLength, hashtag)
+	}
+	return nil
+}
+
+type LiveTextTarget int
+
+const (
+	LiveTextSubtitle LiveTextTarget = iota
+	LiveTextHashtag
+)
+
+// LiveTextRotator rotates the subtitle or hashtag of the current live through Texts.
+// The next text is set every Interval, when CurrentViewCount reaches one of ViewerMilestones,
+// or when Trigger is called. The text is cleared when the live ends.
+type LiveTextRotator struct {
+	MovieService     *MovieService
+	UserId           string // the broadcaster of the bearer token
+	Target           LiveTextTarget
+	Texts            []string
+	Interval         time.Duration // 0 disables timed rotation
+	ViewerMilestones []int
+	PollInterval     time.Duration // not positive polls every 30 seconds
+	UseBearerToken   bool
+
+	mu      sync.Mutex
+	trigger chan struct{}
+}
+
+// defaultLiveTextPollInterval is used by Run when PollInterval is not positive.
+const defaultLiveTextPollInterval = 30 * time.Second
+
+func CreateLiveTextRotator(movieService *MovieService, userId string, target LiveTextTarget, texts []string) *LiveTextRotator {
+	return &LiveTextRotator{
+		MovieService: movieService,
+		UserId:       userId,
+		Target:       target,
+		Texts:        texts,
+		Interval:     5 * time.Minute,
+		PollInterval: defaultLiveTextPollInterval,
+	}
+}
+
+func (liveTextRotator *LiveTextRotator) Validate() error {
+	if len(liveTextRotator.Texts) == 0 {
+		return errors.New("no texts to rotate")
+	}
+	for _, text := range liveTextRotator.Texts {
+		var err error
+		if liveTextRotator.Target == LiveTextHashtag {
+			err = ValidateHashtag(text)
+		} else {
+			err = ValidateSubtitle(text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trigger switches to the next text as soon as Run is able to.
+func (liveTextRotator *LiveTextRotator) Trigger() {
+	select {
+	case liveTextRotator.triggered() <- struct{}{}:
+	default:
+	}
+}
+
+// triggered creates the trigger channel on first use so a LiveTextRotator literal works too.
+func (liveTextRotator *LiveTextRotator) triggered() chan struct{} {
+	liveTextRotator.mu.Lock()
+	defer liveTextRotator.mu.Unlock()
+	if liveTextRotator.trigger == nil {
+		liveTextRotator.trigger = make(chan struct{}, 1)
+	}
+	return liveTextRotator.trigger
+}
+
+// Run waits for the live to start, rotates texts while it is on air and returns nil once it has ended.
+func (liveTextRotator *LiveTextRotator) Run(ctx context.Context) error {
+	logger := *liveTextRotator.MovieService.Logger
+	if err := liveTextRotator.Validate(); err != nil {
+		return err
+	}
+	milestones := append([]int(nil), liveTextRotator.ViewerMilestones...)
+	sort.Ints(milestones)
+
+	movieId := ""
+	next := 0
+	var rotationTicker *time.Ticker
+	var rotation <-chan time.Time
+	defer func() {
+		if rotationTicker != nil {
+			rotationTicker.Stop()
+		}
+	}()
+	pollInterval := liveTextRotator.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultLiveTextPollInterval
+	}
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	trigger := liveTextRotator.triggered()
+	for {
+		rotate := false
+		container, errorResponse, err := liveTextRotator.MovieService.GetCurrentLive(liveTextRotator.UserId, liveTextRotator.UseBearerToken)
+		isLive := err == nil && container.Movie.IsLive
+		switch {
+		case err != nil && !isNotFound(errorResponse):
+			logger.Warn("get current live failed for LiveTextRotator", err)
+		case movieId == "" && isLive:
+			movieId = container.Movie.Id
+			rotate = true
+			if liveTextRotator.Interval > 0 {
+				rotationTicker = time.NewTicker(liveTextRotator.Interval)
+				rotation = rotationTicker.C
+			}
+		case movieId != "" && (!isLive || container.Movie.Id != movieId):
+			liveTextRotator.clear()
+			return nil
+		}
+		if isLive && movieId != "" {
+			for len(milestones) > 0 && container.Movie.CurrentViewCount >= milestones[0] {
+				milestones = milestones[1:]
+				rotate = true
+			}
+		}
+
+	wait:
+		for {
+			if rotate {
+				liveTextRotator.set(liveTextRotator.Texts[next%len(liveTextRotator.Texts)])
+				next++
+				rotate = false
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-rotation:
+				rotate = true
+			case <-trigger:
+				rotate = movieId != ""
+			case <-poll.C:
+				break wait
+			}
+		}
+	}
+}
+
+func (liveTextRotator *LiveTextRotator) set(text string) {
+	logger := *liveTextRotator.MovieService.Logger
+	var err error
+	if liveTextRotator.Target == LiveTextHashtag {
+		_, _, err = liveTextRotator.MovieService.PostCurrentLiveHashtag(text)
+	} else {
+		_, _, err = liveTextRotator.MovieService.PostCurrentLiveSubtitle(text)
+	}
+	if err != nil {
+		logger.Warn("set text failed for LiveTextRotator", text, err)
+	}
+}
+
+func (liveTextRotator *LiveTextRotator) clear() {
+	logger := *liveTextRotator.MovieService.Logger
+	var err error
+	if liveTextRotator.Target == LiveTextHashtag {
+		_, _, err = liveTextRotator.MovieService.DeleteCurrentLiveHashtag()
+	} else {
+		_, _, err = liveTextRotator.MovieService.DeleteCurrentLiveSubtitle()
+	}
+	if err != nil {
+		logger.Warn("clear text failed for LiveTextRotator", err)
+	}
+}
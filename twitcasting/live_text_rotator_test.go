@@ -0,0 +1,142 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeLiveTextServer struct {
+	mu       sync.Mutex
+	live     *twitcasting.Movie
+	requests []string
+}
+
+func (fake *fakeLiveTextServer) setLive(movie *twitcasting.Movie) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.live = movie
+}
+
+func (fake *fakeLiveTextServer) recorded() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return append([]string(nil), fake.requests...)
+}
+
+func (fake *fakeLiveTextServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, "/current_live") {
+		if fake.live == nil {
+			w.WriteHeader(http.StatusNotFound)
+			body, _ := json.Marshal(twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 404, Message: "Not Found"}})
+			_, _ = w.Write(body)
+			return
+		}
+		body, _ := json.Marshal(twitcasting.MovieContainer{Movie: *fake.live})
+		_, _ = w.Write(body)
+		return
+	}
+	b, _ := io.ReadAll(r.Body)
+	fake.requests = append(fake.requests, r.Method+" "+r.URL.Path+" "+string(b))
+	_, _ = w.Write([]byte("{}"))
+}
+
+func TestValidateSubtitleAndHashtag(t *testing.T) {
+	assert.Nil(t, twitcasting.ValidateSubtitle("生きてます"))
+	assert.Nil(t, twitcasting.ValidateSubtitle(strings.Repeat("あ", 17)))
+	assert.NotNil(t, twitcasting.ValidateSubtitle(strings.Repeat("あ", 18)))
+	assert.NotNil(t, twitcasting.ValidateSubtitle(""))
+	assert.Nil(t, twitcasting.ValidateHashtag("#初見さん大歓迎"))
+	assert.NotNil(t, twitcasting.ValidateHashtag("#"+strings.Repeat("a", 26)))
+
+	rotator := twitcasting.CreateLiveTextRotator(nil, "user", twitcasting.LiveTextHashtag, []string{"#ok", "#" + strings.Repeat("a", 26)})
+	assert.NotNil(t, rotator.Validate())
+	rotator.Texts = nil
+	assert.NotNil(t, rotator.Validate())
+}
+
+func TestLiveTextRotator(t *testing.T) {
+	fake := &fakeLiveTextServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	rotator := twitcasting.CreateLiveTextRotator(locator.Movie, "user", twitcasting.LiveTextSubtitle, []string{"one", "two"})
+	rotator.Interval = 0
+	rotator.PollInterval = 5 * time.Millisecond
+	rotator.ViewerMilestones = []int{10}
+
+	done := make(chan error)
+	go func() {
+		done <- rotator.Run(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, fake.recorded())
+
+	fake.setLive(&twitcasting.Movie{Id: "1", IsLive: true, CurrentViewCount: 1})
+	assert.Eventually(t, func() bool { return len(fake.recorded()) == 1 }, time.Second, time.Millisecond)
+	fake.setLive(&twitcasting.Movie{Id: "1", IsLive: true, CurrentViewCount: 12})
+	assert.Eventually(t, func() bool { return len(fake.recorded()) == 2 }, time.Second, time.Millisecond)
+	rotator.Trigger()
+	assert.Eventually(t, func() bool { return len(fake.recorded()) == 3 }, time.Second, time.Millisecond)
+	fake.setLive(nil)
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{
+		"POST /movies/subtitle {\"subtitle\":\"one\"}",
+		"POST /movies/subtitle {\"subtitle\":\"two\"}",
+		"POST /movies/subtitle {\"subtitle\":\"one\"}",
+		"DELETE /movies/subtitle ",
+	}, fake.recorded())
+}
+
+func TestLiveTextRotatorInterval(t *testing.T) {
+	fake := &fakeLiveTextServer{live: &twitcasting.Movie{Id: "1", IsLive: true}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	rotator := twitcasting.CreateLiveTextRotator(locator.Movie, "user", twitcasting.LiveTextHashtag, []string{"#a", "#b"})
+	rotator.Interval = 5 * time.Millisecond
+	rotator.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- rotator.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool { return len(fake.recorded()) >= 3 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	recorded := fake.recorded()
+	assert.Equal(t, "POST /movies/hashtag {\"hashtag\":\"#a\"}", recorded[0])
+	assert.Equal(t, "POST /movies/hashtag {\"hashtag\":\"#b\"}", recorded[1])
+	assert.Equal(t, "POST /movies/hashtag {\"hashtag\":\"#a\"}", recorded[2])
+}
+
+func TestLiveTextRotatorLiteralTrigger(t *testing.T) {
+	fake := &fakeLiveTextServer{live: &twitcasting.Movie{Id: "1", IsLive: true}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	rotator := &twitcasting.LiveTextRotator{MovieService: locator.Movie, UserId: "user", Target: twitcasting.LiveTextHashtag, Texts: []string{"#a", "#b"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- rotator.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool { return len(fake.recorded()) == 1 }, time.Second, time.Millisecond)
+	rotator.Trigger()
+	assert.Eventually(t, func() bool { return len(fake.recorded()) == 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, "POST /movies/hashtag {\"hashtag\":\"#b\"}", fake.recorded()[1])
+}
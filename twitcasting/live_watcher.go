@@ -0,0 +1,197 @@
+package twitcasting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type LiveEventType string
+
+const (
+	LiveStarted        LiveEventType = "live_started"
+	LiveEnded          LiveEventType = "live_ended"
+	TitleChanged       LiveEventType = "title_changed"
+	SubtitleChanged    LiveEventType = "subtitle_changed"
+	CategoryChanged    LiveEventType = "category_changed"
+	ViewerCountUpdated LiveEventType = "viewer_count_updated"
+)
+
+// LiveEvent is emitted by LiveWatcher. Previous is set for change events.
+type LiveEvent struct {
+	Type        LiveEventType
+	UserId      string
+	Movie       Movie
+	Broadcaster Broadcaster
+	Previous    *Movie
+	Time        time.Time
+}
+
+// LiveWatcher polls GetCurrentLive for a set of users and reports changes as LiveEvents.
+type LiveWatcher struct {
+	MovieService   *MovieService
+	Interval       time.Duration
+	Concurrency    int
+	UseBearerToken bool
+
+	mu      sync.Mutex
+	userIds map[string]struct{}
+	lives   map[string]MovieContainer
+	// started and ended hold the id of the last movie started and ended per user, so a stale
+	// response does not emit an event twice.
+	started map[string]string
+	ended   map[string]string
+}
+
+// defaultLiveWatcherInterval is used by Run when Interval is not positive.
+const defaultLiveWatcherInterval = 30 * time.Second
+
+func CreateLiveWatcher(movieService *MovieService, userIds []string) *LiveWatcher {
+	liveWatcher := &LiveWatcher{
+		MovieService: movieService,
+		Interval:     defaultLiveWatcherInterval,
+		Concurrency:  4,
+	}
+	for _, userId := range userIds {
+		liveWatcher.AddUser(userId)
+	}
+	return liveWatcher
+}
+
+func (liveWatcher *LiveWatcher) AddUser(userId string) {
+	liveWatcher.mu.Lock()
+	defer liveWatcher.mu.Unlock()
+	if liveWatcher.userIds == nil {
+		liveWatcher.userIds = map[string]struct{}{}
+	}
+	liveWatcher.userIds[userId] = struct{}{}
+}
+
+// RemoveUser stops watching userId. No LiveEnded event is emitted for a live in progress.
+func (liveWatcher *LiveWatcher) RemoveUser(userId string) {
+	liveWatcher.mu.Lock()
+	defer liveWatcher.mu.Unlock()
+	delete(liveWatcher.userIds, userId)
+	delete(liveWatcher.lives, userId)
+	delete(liveWatcher.started, userId)
+	delete(liveWatcher.ended, userId)
+}
+
+func (liveWatcher *LiveWatcher) UserIds() []string {
+	liveWatcher.mu.Lock()
+	defer liveWatcher.mu.Unlock()
+	userIds := make([]string, 0, len(liveWatcher.userIds))
+	for userId := range liveWatcher.userIds {
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+	return userIds
+}
+
+// Run polls every Interval and sends events until ctx is done, then returns ctx.Err().
+// An Interval that is not positive polls every 30 seconds.
+func (liveWatcher *LiveWatcher) Run(ctx context.Context, events chan<- LiveEvent) error {
+	interval := liveWatcher.Interval
+	if interval <= 0 {
+		interval = defaultLiveWatcherInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, event := range liveWatcher.Poll(ctx) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll checks every watched user once and returns the resulting events.
+func (liveWatcher *LiveWatcher) Poll(ctx context.Context) []LiveEvent {
+	logger := *liveWatcher.MovieService.Logger
+	userIds := liveWatcher.UserIds()
+	var mu sync.Mutex
+	var events []LiveEvent
+	runConcurrently(ctx, len(userIds), liveWatcher.Concurrency, func(i int) {
+		container, errorResponse, err := liveWatcher.MovieService.GetCurrentLive(userIds[i], liveWatcher.UseBearerToken)
+		if err != nil && !isNotFound(errorResponse) {
+			logger.Warn("get current live failed for LiveWatcher", userIds[i], err)
+			return
+		}
+		userEvents := liveWatcher.update(userIds[i], container, time.Now())
+		mu.Lock()
+		events = append(events, userEvents...)
+		mu.Unlock()
+	})
+	return events
+}
+
+func (liveWatcher *LiveWatcher) update(userId string, container *MovieContainer, now time.Time) []LiveEvent {
+	liveWatcher.mu.Lock()
+	defer liveWatcher.mu.Unlock()
+	if _, ok := liveWatcher.userIds[userId]; !ok {
+		return nil
+	}
+	if liveWatcher.lives == nil {
+		liveWatcher.lives = map[string]MovieContainer{}
+		liveWatcher.started = map[string]string{}
+		liveWatcher.ended = map[string]string{}
+	}
+	var events []LiveEvent
+	previous, wasLive := liveWatcher.lives[userId]
+	isLive := container != nil && container.Movie.IsLive
+
+	if wasLive && (!isLive || previous.Movie.Id != container.Movie.Id) {
+		delete(liveWatcher.lives, userId)
+		if liveWatcher.ended[userId] != previous.Movie.Id {
+			liveWatcher.ended[userId] = previous.Movie.Id
+			movie := previous.Movie
+			movie.IsLive = false
+			events = append(events, LiveEvent{Type: LiveEnded, UserId: userId, Movie: movie, Broadcaster: previous.Broadcaster, Time: now})
+		}
+		wasLive = false
+	}
+	if !isLive {
+		return events
+	}
+	if liveWatcher.ended[userId] == container.Movie.Id {
+		return events
+	}
+	liveWatcher.lives[userId] = *container
+	if !wasLive {
+		if liveWatcher.started[userId] != container.Movie.Id {
+			liveWatcher.started[userId] = container.Movie.Id
+			events = append(events, LiveEvent{Type: LiveStarted, UserId: userId, Movie: container.Movie, Broadcaster: container.Broadcaster, Time: now})
+		}
+		return events
+	}
+	change := func(eventType LiveEventType) {
+		events = append(events, LiveEvent{Type: eventType, UserId: userId, Movie: container.Movie, Broadcaster: container.Broadcaster, Previous: &previous.Movie, Time: now})
+	}
+	if previous.Movie.Title != container.Movie.Title {
+		change(TitleChanged)
+	}
+	if previous.Movie.Subtitle != container.Movie.Subtitle {
+		change(SubtitleChanged)
+	}
+	if previous.Movie.Category != container.Movie.Category {
+		change(CategoryChanged)
+	}
+	if previous.Movie.CurrentViewCount != container.Movie.CurrentViewCount {
+		change(ViewerCountUpdated)
+	}
+	return events
+}
+
+// isNotFound reports whether the API answered 404, e.g. GetCurrentLive for a user who is not live.
+func isNotFound(errorResponse *ErrorResponse) bool {
+	return errorResponse != nil && errorResponse.Error.Code == 404
+}
@@ -0,0 +1,145 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCurrentLiveServer struct {
+	mu    sync.Mutex
+	lives map[string]twitcasting.MovieContainer
+}
+
+func (fake *fakeCurrentLiveServer) set(userId string, movie *twitcasting.Movie) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if movie == nil {
+		delete(fake.lives, userId)
+		return
+	}
+	fake.lives[userId] = twitcasting.MovieContainer{Movie: *movie, Broadcaster: twitcasting.Broadcaster{Id: userId}}
+}
+
+func (fake *fakeCurrentLiveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	userId := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/"), "/")[0]
+	live, ok := fake.lives[userId]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		body, _ := json.Marshal(twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 404, Message: "Not Found"}})
+		_, _ = w.Write(body)
+		return
+	}
+	body, _ := json.Marshal(live)
+	_, _ = w.Write(body)
+}
+
+func eventTypes(events []twitcasting.LiveEvent) map[string][]twitcasting.LiveEventType {
+	types := map[string][]twitcasting.LiveEventType{}
+	for _, event := range events {
+		types[event.UserId] = append(types[event.UserId], event.Type)
+	}
+	return types
+}
+
+func TestLiveWatcherPoll(t *testing.T) {
+	fake := &fakeCurrentLiveServer{lives: map[string]twitcasting.MovieContainer{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	watcher := twitcasting.CreateLiveWatcher(locator.Movie, []string{"a", "b"})
+	ctx := context.Background()
+
+	assert.Empty(t, watcher.Poll(ctx))
+
+	fake.set("a", &twitcasting.Movie{Id: "100", Title: "t", IsLive: true, CurrentViewCount: 1})
+	assert.Equal(t, map[string][]twitcasting.LiveEventType{"a": {twitcasting.LiveStarted}}, eventTypes(watcher.Poll(ctx)))
+	assert.Empty(t, watcher.Poll(ctx))
+
+	fake.set("a", &twitcasting.Movie{Id: "100", Title: "t2", Subtitle: "s", Category: "c", IsLive: true, CurrentViewCount: 3})
+	events := watcher.Poll(ctx)
+	assert.Equal(t, map[string][]twitcasting.LiveEventType{"a": {
+		twitcasting.TitleChanged,
+		twitcasting.SubtitleChanged,
+		twitcasting.CategoryChanged,
+		twitcasting.ViewerCountUpdated,
+	}}, eventTypes(events))
+	assert.Equal(t, "t", events[0].Previous.Title)
+	assert.Equal(t, "t2", events[0].Movie.Title)
+
+	// a new movie replaces the previous one without an idle poll in between
+	fake.set("a", &twitcasting.Movie{Id: "101", IsLive: true})
+	fake.set("b", &twitcasting.Movie{Id: "200", IsLive: true})
+	assert.Equal(t, map[string][]twitcasting.LiveEventType{
+		"a": {twitcasting.LiveEnded, twitcasting.LiveStarted},
+		"b": {twitcasting.LiveStarted},
+	}, eventTypes(watcher.Poll(ctx)))
+
+	fake.set("a", nil)
+	fake.set("b", &twitcasting.Movie{Id: "200", IsLive: false})
+	assert.Equal(t, map[string][]twitcasting.LiveEventType{
+		"a": {twitcasting.LiveEnded},
+		"b": {twitcasting.LiveEnded},
+	}, eventTypes(watcher.Poll(ctx)))
+
+	// an ended movie reported as live again is not started twice
+	fake.set("b", &twitcasting.Movie{Id: "200", IsLive: true})
+	assert.Empty(t, watcher.Poll(ctx))
+
+	watcher.RemoveUser("b")
+	assert.Equal(t, []string{"a"}, watcher.UserIds())
+}
+
+func TestLiveWatcherRun(t *testing.T) {
+	fake := &fakeCurrentLiveServer{lives: map[string]twitcasting.MovieContainer{}}
+	fake.set("a", &twitcasting.Movie{Id: "100", IsLive: true})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	watcher := twitcasting.CreateLiveWatcher(locator.Movie, []string{"a"})
+	watcher.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan twitcasting.LiveEvent)
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, events)
+	}()
+	event := <-events
+	assert.Equal(t, twitcasting.LiveStarted, event.Type)
+	assert.Equal(t, "100", event.Movie.Id)
+	fake.set("a", nil)
+	event = <-events
+	assert.Equal(t, twitcasting.LiveEnded, event.Type)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestLiveWatcherRunWithoutInterval(t *testing.T) {
+	fake := &fakeCurrentLiveServer{lives: map[string]twitcasting.MovieContainer{}}
+	fake.set("a", &twitcasting.Movie{Id: "100", IsLive: true})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	watcher := &twitcasting.LiveWatcher{MovieService: locator.Movie}
+	watcher.AddUser("a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan twitcasting.LiveEvent)
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, events)
+	}()
+	assert.Equal(t, twitcasting.LiveStarted, (<-events).Type)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
@@ -0,0 +1,322 @@
+package twitcasting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ModerationAction string
+
+const (
+	ModerationDelete ModerationAction = "delete"
+	ModerationLog    ModerationAction = "log"
+	ModerationNotify ModerationAction = "notify"
+)
+
+// ModerationRule reports whether a comment breaks the rule and why.
+// Rules are called for every comment that is not allowed, so stateful rules see the whole chat.
+type ModerationRule interface {
+	Name() string
+	Match(comment Comment) (reason string, matched bool)
+}
+
+// NgWordRule matches comments containing one of Words. Case and full-width ASCII are ignored.
+type NgWordRule struct {
+	Words []string
+}
+
+func (ngWordRule *NgWordRule) Name() string {
+	return "ng_word"
+}
+
+func (ngWordRule *NgWordRule) Match(comment Comment) (string, bool) {
+	message := normalizeCommentText(comment.Message)
+	for _, word := range ngWordRule.Words {
+		if word != "" && strings.Contains(message, normalizeCommentText(word)) {
+			return fmt.Sprintf("contains %q", word), true
+		}
+	}
+	return "", false
+}
+
+type RegexRule struct {
+	Pattern *regexp.Regexp
+}
+
+func (regexRule *RegexRule) Name() string {
+	return "regex"
+}
+
+func (regexRule *RegexRule) Match(comment Comment) (string, bool) {
+	if regexRule.Pattern.MatchString(comment.Message) {
+		return fmt.Sprintf("matches %v", regexRule.Pattern), true
+	}
+	return "", false
+}
+
+var moderationUrlPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)([^\s/?#:]+)`)
+
+// UrlRule matches comments with a link to a host other than AllowedHosts or their subdomains.
+type UrlRule struct {
+	AllowedHosts []string
+}
+
+func (urlRule *UrlRule) Name() string {
+	return "url"
+}
+
+func (urlRule *UrlRule) Match(comment Comment) (string, bool) {
+	for _, match := range moderationUrlPattern.FindAllStringSubmatch(normalizeCommentText(comment.Message), -1) {
+		host := strings.TrimPrefix(match[1], "www.")
+		if !urlRule.allowed(host) {
+			return fmt.Sprintf("links to %v", host), true
+		}
+	}
+	return "", false
+}
+
+func (urlRule *UrlRule) allowed(host string) bool {
+	for _, allowedHost := range urlRule.AllowedHosts {
+		allowedHost = strings.ToLower(allowedHost)
+		if host == allowedHost || strings.HasSuffix(host, "."+allowedHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepeatRule matches when a user posts the same message Count times within Window.
+// Time is taken from Comment.Created.
+type RepeatRule struct {
+	Count  int
+	Window time.Duration
+
+	history map[string][]moderationPost
+}
+
+type moderationPost struct {
+	created int
+	message string
+}
+
+func (repeatRule *RepeatRule) Name() string {
+	return "repeat"
+}
+
+func (repeatRule *RepeatRule) Match(comment Comment) (string, bool) {
+	if repeatRule.history == nil {
+		repeatRule.history = map[string][]moderationPost{}
+	}
+	message := normalizeCommentText(strings.TrimSpace(comment.Message))
+	posts := append(recentPosts(repeatRule.history[comment.FromUser.Id], comment.Created, repeatRule.Window), moderationPost{comment.Created, message})
+	repeatRule.history[comment.FromUser.Id] = posts
+	count := 0
+	for _, post := range posts {
+		if post.message == message {
+			count++
+		}
+	}
+	if count >= repeatRule.Count {
+		return fmt.Sprintf("posted the same message %d times within %v", count, repeatRule.Window), true
+	}
+	return "", false
+}
+
+// RateRule matches when a user posts more than Limit comments within Window.
+// Time is taken from Comment.Created.
+type RateRule struct {
+	Limit  int
+	Window time.Duration
+
+	history map[string][]moderationPost
+}
+
+func (rateRule *RateRule) Name() string {
+	return "rate"
+}
+
+func (rateRule *RateRule) Match(comment Comment) (string, bool) {
+	if rateRule.history == nil {
+		rateRule.history = map[string][]moderationPost{}
+	}
+	posts := append(recentPosts(rateRule.history[comment.FromUser.Id], comment.Created, rateRule.Window), moderationPost{created: comment.Created})
+	rateRule.history[comment.FromUser.Id] = posts
+	if len(posts) > rateRule.Limit {
+		return fmt.Sprintf("posted %d comments within %v", len(posts), rateRule.Window), true
+	}
+	return "", false
+}
+
+// recentPosts drops the posts older than window at created.
+func recentPosts(posts []moderationPost, created int, window time.Duration) []moderationPost {
+	since := created - int(window/time.Second)
+	for len(posts) > 0 && posts[0].created <= since {
+		posts = posts[1:]
+	}
+	return posts
+}
+
+// ModerationAuditEntry records one action taken, or skipped in dry-run mode, for a comment.
+type ModerationAuditEntry struct {
+	Time    time.Time        `json:"time"`
+	MovieId string           `json:"movie_id"`
+	Comment Comment          `json:"comment"`
+	Rule    string           `json:"rule"`
+	Reason  string           `json:"reason"`
+	Action  ModerationAction `json:"action"`
+	DryRun  bool             `json:"dry_run"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type moderationPolicy struct {
+	rule    ModerationRule
+	actions []ModerationAction
+}
+
+// Moderator evaluates comments of a movie against its rules and performs the actions of the
+// first matching rule. Comments from AllowUserIds are never touched, comments from DenyUserIds
+// always get DenyActions.
+type Moderator struct {
+	CommentService *CommentService
+	MovieId        string
+	AllowUserIds   []string
+	DenyUserIds    []string
+	DenyActions    []ModerationAction
+	// DryRun records the actions in the audit trail without deleting comments.
+	DryRun bool
+	// Notify is called for ModerationNotify actions.
+	Notify func(entry ModerationAuditEntry)
+	// AuditWriter is optional. Every audit entry is appended to it as a JSON line.
+	AuditWriter io.Writer
+
+	mu       sync.Mutex
+	policies []moderationPolicy
+	audit    []ModerationAuditEntry
+	// writeMu keeps the JSON lines of concurrent Moderate calls apart without holding mu.
+	writeMu sync.Mutex
+}
+
+func CreateModerator(commentService *CommentService, movieId string) *Moderator {
+	return &Moderator{
+		CommentService: commentService,
+		MovieId:        movieId,
+		DenyActions:    []ModerationAction{ModerationDelete, ModerationLog},
+	}
+}
+
+// AddRule appends rule, evaluated after the rules added before it.
+func (moderator *Moderator) AddRule(rule ModerationRule, actions ...ModerationAction) {
+	moderator.mu.Lock()
+	defer moderator.mu.Unlock()
+	moderator.policies = append(moderator.policies, moderationPolicy{rule: rule, actions: actions})
+}
+
+// Moderate evaluates comment and returns the audit entries of the actions taken.
+// It can be passed to CommentStream.RunFunc through a closure. The actions run without holding
+// the Moderator's lock, so Notify may call AuditLog.
+func (moderator *Moderator) Moderate(comment Comment) []ModerationAuditEntry {
+	entries, start := moderator.match(comment)
+	for i := range entries {
+		moderator.perform(&entries[i])
+		if entries[i].Error != "" {
+			moderator.mu.Lock()
+			moderator.audit[start+i].Error = entries[i].Error
+			moderator.mu.Unlock()
+		}
+	}
+	moderator.writeAudit(entries)
+	return entries
+}
+
+// match evaluates the rules and appends the entries of the matched actions to the audit log.
+// It returns the entries and the index of the first one in the audit log.
+func (moderator *Moderator) match(comment Comment) ([]ModerationAuditEntry, int) {
+	moderator.mu.Lock()
+	defer moderator.mu.Unlock()
+	if slices.Contains(moderator.AllowUserIds, comment.FromUser.Id) {
+		return nil, 0
+	}
+	var matched *moderationPolicy
+	var reason string
+	if slices.Contains(moderator.DenyUserIds, comment.FromUser.Id) {
+		matched = &moderationPolicy{actions: moderator.DenyActions}
+		reason = "denied user"
+	}
+	for i := range moderator.policies {
+		policy := &moderator.policies[i]
+		// every rule sees the comment so repeat and rate rules keep counting
+		if ruleReason, ok := policy.rule.Match(comment); ok && matched == nil {
+			matched = policy
+			reason = ruleReason
+		}
+	}
+	if matched == nil {
+		return nil, 0
+	}
+	ruleName := "deny_list"
+	if matched.rule != nil {
+		ruleName = matched.rule.Name()
+	}
+	var entries []ModerationAuditEntry
+	for _, action := range matched.actions {
+		entries = append(entries, ModerationAuditEntry{
+			Time:    time.Now(),
+			MovieId: moderator.MovieId,
+			Comment: comment,
+			Rule:    ruleName,
+			Reason:  reason,
+			Action:  action,
+			DryRun:  moderator.DryRun,
+		})
+	}
+	start := len(moderator.audit)
+	moderator.audit = append(moderator.audit, entries...)
+	return entries, start
+}
+
+// AuditLog returns every entry recorded so far, oldest first.
+func (moderator *Moderator) AuditLog() []ModerationAuditEntry {
+	moderator.mu.Lock()
+	defer moderator.mu.Unlock()
+	return append([]ModerationAuditEntry(nil), moderator.audit...)
+}
+
+func (moderator *Moderator) perform(entry *ModerationAuditEntry) {
+	logger := *moderator.CommentService.Logger
+	switch entry.Action {
+	case ModerationDelete:
+		if !entry.DryRun {
+			if _, _, err := moderator.CommentService.DeleteComment(entry.MovieId, entry.Comment.Id); err != nil {
+				logger.Error("delete comment failed for Moderator", entry.Comment.Id, err)
+				entry.Error = err.Error()
+			}
+		}
+	case ModerationLog:
+		logger.Info("moderation", entry.Rule, entry.Reason, entry.Comment.FromUser.Id, entry.Comment.Message)
+	case ModerationNotify:
+		if moderator.Notify != nil {
+			moderator.Notify(*entry)
+		}
+	}
+}
+
+func (moderator *Moderator) writeAudit(entries []ModerationAuditEntry) {
+	if moderator.AuditWriter == nil || len(entries) == 0 {
+		return
+	}
+	logger := *moderator.CommentService.Logger
+	moderator.writeMu.Lock()
+	defer moderator.writeMu.Unlock()
+	encoder := json.NewEncoder(moderator.AuditWriter)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			logger.Error("write audit entry failed for Moderator", err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package twitcasting_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeDeleteServer struct {
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (fake *fakeDeleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	commentId := path.Base(r.URL.Path)
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	fake.deleted = append(fake.deleted, commentId)
+	body, _ := json.Marshal(twitcasting.DeleteCommentContainer{CommentId: commentId})
+	_, _ = w.Write(body)
+}
+
+func moderationComment(id string, userId string, message string, created int) twitcasting.Comment {
+	return twitcasting.Comment{Id: id, Message: message, FromUser: twitcasting.User{Id: userId}, Created: created}
+}
+
+func TestModerationRules(t *testing.T) {
+	ngWord := &twitcasting.NgWordRule{Words: []string{"spam"}}
+	_, matched := ngWord.Match(moderationComment("1", "a", "ＳＰＡＭ here", 0))
+	assert.True(t, matched)
+	_, matched = ngWord.Match(moderationComment("1", "a", "hello", 0))
+	assert.False(t, matched)
+
+	regex := &twitcasting.RegexRule{Pattern: regexp.MustCompile(`\d{3}-\d{4}-\d{4}`)}
+	_, matched = regex.Match(moderationComment("1", "a", "call 090-1234-5678", 0))
+	assert.True(t, matched)
+
+	url := &twitcasting.UrlRule{AllowedHosts: []string{"twitcasting.tv"}}
+	_, matched = url.Match(moderationComment("1", "a", "see https://en.twitcasting.tv/user", 0))
+	assert.False(t, matched)
+	reason, matched := url.Match(moderationComment("1", "a", "see www.example.com", 0))
+	assert.True(t, matched)
+	assert.Equal(t, "links to example.com", reason)
+
+	repeat := &twitcasting.RepeatRule{Count: 3, Window: 10 * time.Second}
+	_, first := repeat.Match(moderationComment("1", "a", "hi", 100))
+	_, second := repeat.Match(moderationComment("2", "a", "hi ", 101))
+	_, other := repeat.Match(moderationComment("3", "b", "hi", 102))
+	_, third := repeat.Match(moderationComment("4", "a", "HI", 105))
+	_, expired := repeat.Match(moderationComment("5", "a", "hi", 120))
+	assert.Equal(t, []bool{false, false, false, true, false}, []bool{first, second, other, third, expired})
+
+	rate := &twitcasting.RateRule{Limit: 2, Window: 5 * time.Second}
+	_, first = rate.Match(moderationComment("1", "a", "x", 100))
+	_, second = rate.Match(moderationComment("2", "a", "y", 101))
+	_, third = rate.Match(moderationComment("3", "a", "z", 102))
+	_, expired = rate.Match(moderationComment("4", "a", "z", 110))
+	assert.Equal(t, []bool{false, false, true, false}, []bool{first, second, third, expired})
+}
+
+func TestModerator(t *testing.T) {
+	fake := &fakeDeleteServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	var audit bytes.Buffer
+	var notified []twitcasting.ModerationAuditEntry
+	moderator := twitcasting.CreateModerator(locator.Comment, "100")
+	moderator.AllowUserIds = []string{"owner"}
+	moderator.DenyUserIds = []string{"troll"}
+	moderator.AuditWriter = &audit
+	moderator.Notify = func(entry twitcasting.ModerationAuditEntry) {
+		notified = append(notified, entry)
+	}
+	moderator.AddRule(&twitcasting.NgWordRule{Words: []string{"spam"}}, twitcasting.ModerationDelete, twitcasting.ModerationNotify)
+	moderator.AddRule(&twitcasting.UrlRule{}, twitcasting.ModerationLog)
+
+	assert.Empty(t, moderator.Moderate(moderationComment("1", "owner", "spam https://example.com", 0)))
+	assert.Empty(t, moderator.Moderate(moderationComment("2", "viewer", "hello", 0)))
+	entries := moderator.Moderate(moderationComment("3", "viewer", "spam https://example.com", 0))
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "ng_word", entries[0].Rule)
+	assert.Equal(t, twitcasting.ModerationDelete, entries[0].Action)
+	assert.Equal(t, twitcasting.ModerationNotify, entries[1].Action)
+	entries = moderator.Moderate(moderationComment("4", "viewer", "https://example.com", 0))
+	assert.Equal(t, "url", entries[0].Rule)
+	assert.Equal(t, twitcasting.ModerationLog, entries[0].Action)
+	entries = moderator.Moderate(moderationComment("5", "troll", "hello", 0))
+	assert.Equal(t, "deny_list", entries[0].Rule)
+
+	assert.Equal(t, []string{"3", "5"}, fake.deleted)
+	assert.Equal(t, 1, len(notified))
+	assert.Equal(t, 5, len(moderator.AuditLog()))
+	assert.Equal(t, 5, strings.Count(audit.String(), "\n"))
+}
+
+func TestModeratorDryRun(t *testing.T) {
+	fake := &fakeDeleteServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	moderator := twitcasting.CreateModerator(locator.Comment, "100")
+	moderator.DryRun = true
+	moderator.AddRule(&twitcasting.NgWordRule{Words: []string{"spam"}}, twitcasting.ModerationDelete)
+
+	entries := moderator.Moderate(moderationComment("1", "viewer", "spam", 0))
+	assert.Equal(t, 1, len(entries))
+	assert.True(t, entries[0].DryRun)
+	assert.Empty(t, fake.deleted)
+	assert.Equal(t, entries, moderator.AuditLog())
+}
+
+func TestModeratorNotifyReadsAuditLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":1000,"message":"Invalid token"}}`))
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	moderator := twitcasting.CreateModerator(locator.Comment, "100")
+	var logged []twitcasting.ModerationAuditEntry
+	moderator.Notify = func(entry twitcasting.ModerationAuditEntry) {
+		logged = moderator.AuditLog()
+	}
+	moderator.AddRule(&twitcasting.NgWordRule{Words: []string{"spam"}}, twitcasting.ModerationDelete, twitcasting.ModerationNotify)
+
+	done := make(chan []twitcasting.ModerationAuditEntry)
+	go func() {
+		done <- moderator.Moderate(moderationComment("1", "viewer", "spam", 0))
+	}()
+	select {
+	case entries := <-done:
+		assert.Equal(t, 2, len(entries))
+		assert.Equal(t, "error response", entries[0].Error)
+	case <-time.After(time.Second):
+		t.Fatal("Moderate did not return")
+	}
+	assert.Equal(t, 2, len(logged))
+	assert.Equal(t, "error response", moderator.AuditLog()[0].Error)
+}
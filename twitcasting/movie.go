@@ -107,8 +107,22 @@ func (movieService *MovieService) GetMovie(movieId string, useBearerToken bool)
 
 // GetUserMovies @see https://apiv2-doc.twitcasting.tv//#get-movies-by-user
 func (movieService *MovieService) GetUserMovies(userId string, limit int, offset int, useBearerToken bool) (*UserMoviesContainer, *ErrorResponse, error) {
+	return movieService.GetUserMoviesWith(GetUserMoviesRequest{UserId: userId, Limit: limit, Offset: offset, UseBearerToken: useBearerToken})
+}
+
+// GetUserMoviesBySliceId @see https://apiv2-doc.twitcasting.tv//#get-movies-by-user
+func (movieService *MovieService) GetUserMoviesBySliceId(userId string, limit int, sliceId string, useBearerToken bool) (*UserMoviesContainer, *ErrorResponse, error) {
+	return movieService.GetUserMoviesWith(GetUserMoviesRequest{UserId: userId, Limit: limit, SliceId: sliceId, UseBearerToken: useBearerToken})
+}
+
+// GetUserMoviesWith @see https://apiv2-doc.twitcasting.tv//#get-movies-by-user
+func (movieService *MovieService) GetUserMoviesWith(request GetUserMoviesRequest) (*UserMoviesContainer, *ErrorResponse, error) {
 	logger := *movieService.Logger
-	response, err := movieService.Client.get(fmt.Sprintf("/users/%v/movies?limit=%v&offset=%v", userId, limit, offset), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetUserMovies", err)
+		return nil, nil, err
+	}
+	response, err := movieService.Client.get(fmt.Sprintf("/users/%v/movies?%v", request.UserId, request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for GetUserMovies", err)
 		return nil, nil, err
@@ -135,36 +149,6 @@ func (movieService *MovieService) GetUserMovies(userId string, limit int, offset
 	}
 }
 
-// GetUserMoviesBySliceId @see https://apiv2-doc.twitcasting.tv//#get-movies-by-user
-func (movieService *MovieService) GetUserMoviesBySliceId(userId string, limit int, sliceId string, useBearerToken bool) (*UserMoviesContainer, *ErrorResponse, error) {
-	logger := *movieService.Logger
-	response, err := movieService.Client.get(fmt.Sprintf("/users/%v/movies?limit=%v&slice_id=%v", userId, limit, sliceId), useBearerToken)
-	if err != nil {
-		logger.Error("request failed for GetUserMoviesBySliceId", err)
-		return nil, nil, err
-	}
-	defer movieService.Client.BodyClose(response.Body)
-	if response.StatusCode == 200 {
-		req := new(UserMoviesContainer)
-		err = json.NewDecoder(response.Body).Decode(req)
-		if err != nil {
-			logger.Error("decode response body failed for GetUserMoviesBySliceId", err)
-			return nil, nil, err
-		}
-		logger.Debug("response for GetUserMoviesBySliceId", req)
-		return req, nil, nil
-	} else {
-		req := new(ErrorResponse)
-		err = json.NewDecoder(response.Body).Decode(req)
-		if err != nil {
-			logger.Error("decode error response body failed for GetUserMoviesBySliceId", err)
-			return nil, nil, err
-		}
-		logger.Debug("error response for GetUserMoviesBySliceId", req)
-		return nil, req, errors.New("error response")
-	}
-}
-
 // GetCurrentLive @see https://apiv2-doc.twitcasting.tv/#get-current-live
 func (movieService *MovieService) GetCurrentLive(userId string, useBearerToken bool) (*MovieContainer, *ErrorResponse, error) {
 	logger := *movieService.Logger
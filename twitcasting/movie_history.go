@@ -0,0 +1,108 @@
+package twitcasting
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type MovieSnapshot struct {
+	Time      time.Time      `json:"time"`
+	Container MovieContainer `json:"container"`
+}
+
+// MovieHistoryChange lists the fields that changed between the previous snapshot and the one taken at Time.
+type MovieHistoryChange struct {
+	Time    time.Time          `json:"time"`
+	Changes []MovieFieldChange `json:"changes"`
+}
+
+// MovieHistory is a time series of MovieContainer snapshots.
+type MovieHistory struct {
+	mu        sync.Mutex
+	snapshots []MovieSnapshot
+}
+
+// DiffMovieContainers returns the changed movie fields and tags between two snapshots.
+func DiffMovieContainers(before MovieContainer, after MovieContainer) []MovieFieldChange {
+	changes := DiffMovies(before.Movie, after.Movie)
+	if !slices.Equal(before.Tags, after.Tags) {
+		changes = append(changes, MovieFieldChange{Field: "tags", Before: before.Tags, After: after.Tags})
+	}
+	return changes
+}
+
+// Record appends a snapshot. Snapshots are kept in time order.
+func (movieHistory *MovieHistory) Record(container MovieContainer, at time.Time) {
+	movieHistory.mu.Lock()
+	defer movieHistory.mu.Unlock()
+	i := len(movieHistory.snapshots)
+	for i > 0 && movieHistory.snapshots[i-1].Time.After(at) {
+		i--
+	}
+	movieHistory.snapshots = slices.Insert(movieHistory.snapshots, i, MovieSnapshot{Time: at, Container: container})
+}
+
+func (movieHistory *MovieHistory) Snapshots() []MovieSnapshot {
+	movieHistory.mu.Lock()
+	defer movieHistory.mu.Unlock()
+	return slices.Clone(movieHistory.snapshots)
+}
+
+// Between returns the snapshots taken in [from, to].
+func (movieHistory *MovieHistory) Between(from time.Time, to time.Time) []MovieSnapshot {
+	var snapshots []MovieSnapshot
+	for _, snapshot := range movieHistory.Snapshots() {
+		if !snapshot.Time.Before(from) && !snapshot.Time.After(to) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots
+}
+
+// Changes diffs each snapshot against the previous one and omits snapshots without changes.
+func (movieHistory *MovieHistory) Changes() []MovieHistoryChange {
+	snapshots := movieHistory.Snapshots()
+	var changes []MovieHistoryChange
+	for i := 1; i < len(snapshots); i++ {
+		diff := DiffMovieContainers(snapshots[i-1].Container, snapshots[i].Container)
+		if len(diff) > 0 {
+			changes = append(changes, MovieHistoryChange{Time: snapshots[i].Time, Changes: diff})
+		}
+	}
+	return changes
+}
+
+// FieldChanges returns only the changes of a single field, e.g. "current_view_count".
+func (movieHistory *MovieHistory) FieldChanges(field string) []MovieHistoryChange {
+	var changes []MovieHistoryChange
+	for _, change := range movieHistory.Changes() {
+		for _, fieldChange := range change.Changes {
+			if fieldChange.Field == field {
+				changes = append(changes, MovieHistoryChange{Time: change.Time, Changes: []MovieFieldChange{fieldChange}})
+			}
+		}
+	}
+	return changes
+}
+
+// RecordMovieHistory takes a snapshot of movieId with GetMovie every interval into history.
+// It returns nil after the first snapshot in which the movie is no longer live.
+func (movieService *MovieService) RecordMovieHistory(ctx context.Context, movieId string, interval time.Duration, history *MovieHistory, useBearerToken bool) error {
+	logger := *movieService.Logger
+	for {
+		container, _, err := movieService.GetMovie(movieId, useBearerToken)
+		if err != nil {
+			logger.Warn("get movie failed for RecordMovieHistory", err)
+		} else {
+			history.Record(*container, time.Now())
+			if !container.Movie.IsLive {
+				return nil
+			}
+		}
+		if err = sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMovieHistoryChanges(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	history := &twitcasting.MovieHistory{}
+	history.Record(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "1", Title: "a", CurrentViewCount: 3}, Tags: []string{"x"}}, start.Add(2*time.Minute))
+	history.Record(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "1", Title: "a", CurrentViewCount: 1}}, start)
+	history.Record(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "1", Title: "a", CurrentViewCount: 1}}, start.Add(time.Minute))
+	history.Record(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "1", Title: "b", CurrentViewCount: 3, LastOwnerComment: "hi"}, Tags: []string{"x"}}, start.Add(3*time.Minute))
+
+	snapshots := history.Snapshots()
+	assert.Equal(t, 4, len(snapshots))
+	assert.Equal(t, start, snapshots[0].Time)
+	assert.Equal(t, 2, len(history.Between(start.Add(time.Minute), start.Add(2*time.Minute))))
+
+	assert.Equal(t, []twitcasting.MovieHistoryChange{
+		{Time: start.Add(2 * time.Minute), Changes: []twitcasting.MovieFieldChange{
+			{Field: "current_view_count", Before: 1, After: 3},
+			{Field: "tags", Before: []string(nil), After: []string{"x"}},
+		}},
+		{Time: start.Add(3 * time.Minute), Changes: []twitcasting.MovieFieldChange{
+			{Field: "title", Before: "a", After: "b"},
+			{Field: "last_owner_comment", Before: "", After: "hi"},
+		}},
+	}, history.Changes())
+	assert.Equal(t, []twitcasting.MovieHistoryChange{
+		{Time: start.Add(3 * time.Minute), Changes: []twitcasting.MovieFieldChange{{Field: "title", Before: "a", After: "b"}}},
+	}, history.FieldChanges("title"))
+}
+
+func TestRecordMovieHistory(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		assert.Equal(t, "/movies/100", r.URL.Path)
+		body, _ := json.Marshal(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "100", IsLive: polls < 3, CurrentViewCount: polls}})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	history := &twitcasting.MovieHistory{}
+	err := locator.Movie.RecordMovieHistory(context.Background(), "100", time.Millisecond, history, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(history.Snapshots()))
+	assert.Equal(t, 2, len(history.FieldChanges("current_view_count")))
+	assert.Equal(t, 1, len(history.FieldChanges("is_live")))
+}
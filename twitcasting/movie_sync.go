@@ -0,0 +1,253 @@
+package twitcasting
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// userMoviesPageLimit is the maximum limit accepted by the get-movies-by-user endpoint.
+const userMoviesPageLimit = 50
+
+// MovieSyncState is the per-user progress of SyncUserMovies.
+type MovieSyncState struct {
+	NewestId         string `json:"newest_id"`
+	OldestId         string `json:"oldest_id"`
+	BackfillComplete bool   `json:"backfill_complete"`
+}
+
+// MovieStore is the caller-supplied storage used by SyncUserMovies.
+// LoadSyncState and LoadMovie return nil without error when nothing is stored yet.
+type MovieStore interface {
+	LoadSyncState(userId string) (*MovieSyncState, error)
+	SaveSyncState(userId string, state MovieSyncState) error
+	LoadMovie(movieId string) (*Movie, error)
+	SaveMovie(movie Movie) error
+}
+
+type MovieFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type MovieUpdate struct {
+	Movie   Movie              `json:"movie"`
+	Changes []MovieFieldChange `json:"changes"`
+}
+
+type MovieSyncResult struct {
+	Added   []Movie       `json:"added"`
+	Updated []MovieUpdate `json:"updated"`
+}
+
+// DiffMovies returns the fields that differ between two versions of the same movie.
+// Field names are the json names used by the API.
+func DiffMovies(before Movie, after Movie) []MovieFieldChange {
+	var changes []MovieFieldChange
+	add := func(field string, b interface{}, a interface{}) {
+		if b != a {
+			changes = append(changes, MovieFieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("title", before.Title, after.Title)
+	add("subtitle", before.Subtitle, after.Subtitle)
+	add("last_owner_comment", before.LastOwnerComment, after.LastOwnerComment)
+	add("category", before.Category, after.Category)
+	add("is_live", before.IsLive, after.IsLive)
+	add("is_recorded", before.IsRecorded, after.IsRecorded)
+	add("is_protected", before.IsProtected, after.IsProtected)
+	add("comment_count", before.CommentCount, after.CommentCount)
+	add("duration", before.Duration, after.Duration)
+	add("max_view_count", before.MaxViewCount, after.MaxViewCount)
+	add("current_view_count", before.CurrentViewCount, after.CurrentViewCount)
+	add("total_view_count", before.TotalViewCount, after.TotalViewCount)
+	return changes
+}
+
+// compareIds compares two numeric ids such as movie or comment ids.
+func compareIds(a string, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// SyncUserMovies stores every movie of userId into store.
+// The first run walks the whole history with slice ids and can be resumed if interrupted,
+// later runs only fetch pages until the newest movie seen so far is reached.
+func (movieService *MovieService) SyncUserMovies(userId string, store MovieStore, useBearerToken bool) (*MovieSyncResult, *ErrorResponse, error) {
+	logger := *movieService.Logger
+	state, err := store.LoadSyncState(userId)
+	if err != nil {
+		logger.Error("load sync state failed for SyncUserMovies", err)
+		return nil, nil, err
+	}
+	if state == nil {
+		state = &MovieSyncState{}
+	}
+	result := &MovieSyncResult{}
+	previousNewestId := state.NewestId
+	fromTop := state.OldestId == ""
+
+	if !state.BackfillComplete {
+		for {
+			page, errorResponse, err := movieService.fetchMoviesPage(userId, state.OldestId, useBearerToken)
+			if err != nil {
+				return result, errorResponse, err
+			}
+			if err = movieService.storeMovies(page.Movies, store, result); err != nil {
+				return result, nil, err
+			}
+			for _, movie := range page.Movies {
+				if state.NewestId == "" || compareIds(movie.Id, state.NewestId) > 0 {
+					state.NewestId = movie.Id
+				}
+			}
+			if len(page.Movies) > 0 {
+				state.OldestId = page.Movies[len(page.Movies)-1].Id
+			}
+			if len(page.Movies) < userMoviesPageLimit {
+				state.BackfillComplete = true
+			}
+			if err = store.SaveSyncState(userId, *state); err != nil {
+				logger.Error("save sync state failed for SyncUserMovies", err)
+				return result, nil, err
+			}
+			if state.BackfillComplete {
+				break
+			}
+		}
+		if fromTop {
+			return result, nil, nil
+		}
+	}
+
+	// Fetch movies newer than the newest one stored before this run.
+	sliceId := ""
+	for {
+		page, errorResponse, err := movieService.fetchMoviesPage(userId, sliceId, useBearerToken)
+		if err != nil {
+			return result, errorResponse, err
+		}
+		if err = movieService.storeMovies(page.Movies, store, result); err != nil {
+			return result, nil, err
+		}
+		reached := false
+		for _, movie := range page.Movies {
+			if compareIds(movie.Id, state.NewestId) > 0 {
+				state.NewestId = movie.Id
+			}
+			if previousNewestId != "" && compareIds(movie.Id, previousNewestId) <= 0 {
+				reached = true
+			}
+		}
+		if reached || len(page.Movies) < userMoviesPageLimit {
+			break
+		}
+		sliceId = page.Movies[len(page.Movies)-1].Id
+	}
+	if err = store.SaveSyncState(userId, *state); err != nil {
+		logger.Error("save sync state failed for SyncUserMovies", err)
+		return result, nil, err
+	}
+	return result, nil, nil
+}
+
+func (movieService *MovieService) fetchMoviesPage(userId string, sliceId string, useBearerToken bool) (*UserMoviesContainer, *ErrorResponse, error) {
+	if sliceId == "" {
+		return movieService.GetUserMovies(userId, userMoviesPageLimit, 0, useBearerToken)
+	}
+	return movieService.GetUserMoviesBySliceId(userId, userMoviesPageLimit, sliceId, useBearerToken)
+}
+
+func (movieService *MovieService) storeMovies(movies []Movie, store MovieStore, result *MovieSyncResult) error {
+	logger := *movieService.Logger
+	for _, movie := range movies {
+		stored, err := store.LoadMovie(movie.Id)
+		if err != nil {
+			logger.Error("load movie failed for SyncUserMovies", err)
+			return err
+		}
+		if stored == nil {
+			result.Added = append(result.Added, movie)
+		} else {
+			changes := DiffMovies(*stored, movie)
+			if len(changes) == 0 {
+				continue
+			}
+			result.Updated = append(result.Updated, MovieUpdate{Movie: movie, Changes: changes})
+		}
+		if err = store.SaveMovie(movie); err != nil {
+			logger.Error("save movie failed for SyncUserMovies", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryMovieStore is an in-memory MovieStore.
+type MemoryMovieStore struct {
+	mu     sync.Mutex
+	states map[string]MovieSyncState
+	movies map[string]Movie
+}
+
+func (store *MemoryMovieStore) LoadSyncState(userId string) (*MovieSyncState, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	state, ok := store.states[userId]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (store *MemoryMovieStore) SaveSyncState(userId string, state MovieSyncState) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.states == nil {
+		store.states = map[string]MovieSyncState{}
+	}
+	store.states[userId] = state
+	return nil
+}
+
+func (store *MemoryMovieStore) LoadMovie(movieId string) (*Movie, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	movie, ok := store.movies[movieId]
+	if !ok {
+		return nil, nil
+	}
+	return &movie, nil
+}
+
+func (store *MemoryMovieStore) SaveMovie(movie Movie) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.movies == nil {
+		store.movies = map[string]Movie{}
+	}
+	store.movies[movie.Id] = movie
+	return nil
+}
+
+// Movies returns the stored movies of userId, newest first.
+func (store *MemoryMovieStore) Movies(userId string) []Movie {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var movies []Movie
+	for _, movie := range store.movies {
+		if movie.UserId == userId {
+			movies = append(movies, movie)
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		return compareIds(movies[i].Id, movies[j].Id) > 0
+	})
+	return movies
+}
@@ -0,0 +1,112 @@
+package twitcasting_test
+
+import (
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type fakeMoviesServer struct {
+	mu       sync.Mutex
+	movies   []twitcasting.Movie // newest first
+	requests int
+}
+
+func (fake *fakeMoviesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests++
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	movies := fake.movies
+	if sliceId := r.URL.Query().Get("slice_id"); sliceId != "" {
+		id, _ := strconv.Atoi(sliceId)
+		for i, movie := range movies {
+			movieId, _ := strconv.Atoi(movie.Id)
+			if movieId < id {
+				movies = movies[i:]
+				break
+			}
+			if i == len(movies)-1 {
+				movies = nil
+			}
+		}
+	} else {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		movies = movies[min(offset, len(movies)):]
+	}
+	movies = movies[:min(limit, len(movies))]
+	body, _ := json.Marshal(twitcasting.UserMoviesContainer{Movies: movies, TotalCount: len(fake.movies)})
+	_, _ = w.Write(body)
+}
+
+func createMovies(from int, to int) []twitcasting.Movie {
+	var movies []twitcasting.Movie
+	for id := to; id >= from; id-- {
+		movies = append(movies, twitcasting.Movie{Id: strconv.Itoa(id), UserId: "user", Title: "title"})
+	}
+	return movies
+}
+
+func TestSyncUserMovies(t *testing.T) {
+	fake := &fakeMoviesServer{movies: createMovies(1000, 1119)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.MemoryMovieStore{}
+
+	result, errorResponse, err := locator.Movie.SyncUserMovies("user", store, false)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, 120, len(result.Added))
+	assert.Equal(t, 0, len(result.Updated))
+	assert.Equal(t, 3, fake.requests)
+	state, _ := store.LoadSyncState("user")
+	assert.Equal(t, &twitcasting.MovieSyncState{NewestId: "1119", OldestId: "1000", BackfillComplete: true}, state)
+
+	fake.movies = append(createMovies(1120, 1121), fake.movies...)
+	fake.movies[2].Title = "renamed"
+	fake.movies[2].IsRecorded = true
+	fake.requests = 0
+	result, errorResponse, err = locator.Movie.SyncUserMovies("user", store, false)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, 1, fake.requests)
+	assert.Equal(t, []twitcasting.Movie{fake.movies[0], fake.movies[1]}, result.Added)
+	assert.Equal(t, []twitcasting.MovieUpdate{{
+		Movie: fake.movies[2],
+		Changes: []twitcasting.MovieFieldChange{
+			{Field: "title", Before: "title", After: "renamed"},
+			{Field: "is_recorded", Before: false, After: true},
+		},
+	}}, result.Updated)
+	assert.Equal(t, 122, len(store.Movies("user")))
+	assert.Equal(t, "1121", store.Movies("user")[0].Id)
+}
+
+func TestSyncUserMoviesResume(t *testing.T) {
+	fake := &fakeMoviesServer{movies: createMovies(1000, 1079)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	store := &twitcasting.MemoryMovieStore{}
+	_ = store.SaveSyncState("user", twitcasting.MovieSyncState{NewestId: "1079", OldestId: "1030"})
+
+	result, errorResponse, err := locator.Movie.SyncUserMovies("user", store, false)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, 80, len(result.Added))
+	state, _ := store.LoadSyncState("user")
+	assert.Equal(t, &twitcasting.MovieSyncState{NewestId: "1079", OldestId: "1000", BackfillComplete: true}, state)
+}
+
+func TestDiffMovies(t *testing.T) {
+	before := twitcasting.Movie{Id: "1", Title: "a", CurrentViewCount: 1}
+	after := twitcasting.Movie{Id: "1", Title: "a", CurrentViewCount: 5}
+	assert.Equal(t, []twitcasting.MovieFieldChange{{Field: "current_view_count", Before: 1, After: 5}}, twitcasting.DiffMovies(before, after))
+	assert.Nil(t, twitcasting.DiffMovies(before, before))
+}
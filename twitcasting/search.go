@@ -18,8 +18,17 @@ type SearchService ServiceBase
 
 // SearchUsers https://apiv2-doc.twitcasting.tv/#search-users
 func (searchService *SearchService) SearchUsers(words string, limit int, useBearerToken bool) (*SearchUsersContainer, *ErrorResponse, error) {
+	return searchService.SearchUsersWith(SearchUsersRequest{Words: words, Limit: limit, UseBearerToken: useBearerToken})
+}
+
+// SearchUsersWith https://apiv2-doc.twitcasting.tv/#search-users
+func (searchService *SearchService) SearchUsersWith(request SearchUsersRequest) (*SearchUsersContainer, *ErrorResponse, error) {
 	logger := *searchService.Logger
-	response, err := searchService.Client.get(fmt.Sprintf("/search/users?words=%v&limit=%v&lang=ja", words, limit), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for SearchUsers", err)
+		return nil, nil, err
+	}
+	response, err := searchService.Client.get(fmt.Sprintf("/search/users?%v", request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for SearchUsers", err)
 		return nil, nil, err
@@ -48,8 +57,17 @@ func (searchService *SearchService) SearchUsers(words string, limit int, useBear
 
 // SearchLiveMovies https://apiv2-doc.twitcasting.tv/#search-live-movies
 func (searchService *SearchService) SearchLiveMovies(contextType string, context string, limit int, useBearerToken bool) (*SearchLiveMoviesContainer, *ErrorResponse, error) {
+	return searchService.SearchLiveMoviesWith(SearchLiveMoviesRequest{Type: contextType, Context: context, Limit: limit, UseBearerToken: useBearerToken})
+}
+
+// SearchLiveMoviesWith https://apiv2-doc.twitcasting.tv/#search-live-movies
+func (searchService *SearchService) SearchLiveMoviesWith(request SearchLiveMoviesRequest) (*SearchLiveMoviesContainer, *ErrorResponse, error) {
 	logger := *searchService.Logger
-	response, err := searchService.Client.get(fmt.Sprintf("/search/lives?type=%v&context=%v&limit=%v&lang=ja", contextType, context, limit), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for SearchLiveMovies", err)
+		return nil, nil, err
+	}
+	response, err := searchService.Client.get(fmt.Sprintf("/search/lives?%v", request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for SearchLiveMovies", err)
 		return nil, nil, err
@@ -1,11 +1,15 @@
 package twitcasting
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 )
 
+// supportTargetUserIdsLimit is the maximum number of target_user_ids accepted by a support or unsupport request.
+const supportTargetUserIdsLimit = 20
+
 type SupportingStatusContainer struct {
 	IsSupporting bool `json:"is_supporting"`
 	Supported    int  `json:"supported"`
@@ -15,6 +19,16 @@ type SupportingStatusContainer struct {
 type SupporterListContainer struct {
 	Total      int             `json:"total"`
 	Supporting []SupporterUser `json:"supporting"`
+	// Supporters is returned by GetSupporterList instead of Supporting.
+	Supporters []SupporterUser `json:"supporters,omitempty"`
+}
+
+// Users returns the listed users of either GetSupportingList or GetSupporterList.
+func (supporterListContainer *SupporterListContainer) Users() []SupporterUser {
+	if len(supporterListContainer.Supporters) > 0 {
+		return supporterListContainer.Supporters
+	}
+	return supporterListContainer.Supporting
 }
 
 type SupporterUser struct {
@@ -42,6 +56,24 @@ type DeleteSupportContainer struct {
 	RemovedCount int `json:"removed_count"`
 }
 
+// SupportChunkError reports a chunk of PostSupport or DeleteSupport that failed. The counts of the
+// other chunks are still returned, together with the SupportChunkErrors joined by errors.Join.
+type SupportChunkError struct {
+	// Offset is the index of the chunk's first id in targetUserIds.
+	Offset        int
+	TargetUserIds []string
+	ErrorResponse *ErrorResponse
+	Err           error
+}
+
+func (supportChunkError *SupportChunkError) Error() string {
+	return fmt.Sprintf("chunk of %d target users from offset %d failed: %v", len(supportChunkError.TargetUserIds), supportChunkError.Offset, supportChunkError.Err)
+}
+
+func (supportChunkError *SupportChunkError) Unwrap() error {
+	return supportChunkError.Err
+}
+
 type SupporterService ServiceBase
 
 // GetSupportingStatus https://apiv2-doc.twitcasting.tv/#get-supporting-status
@@ -76,7 +108,91 @@ func (supporterService *SupporterService) GetSupportingStatus(userId string, tar
 
 // PostSupport https://apiv2-doc.twitcasting.tv/#support-user
 // PostSupport Requests can only be made using a Bearer Token.
+// See PostSupportContext for more than 20 targetUserIds.
 func (supporterService *SupporterService) PostSupport(targetUserIds []string) (*PostSupportContainer, *ErrorResponse, error) {
+	return supporterService.PostSupportContext(context.Background(), targetUserIds)
+}
+
+// PostSupportContext https://apiv2-doc.twitcasting.tv/#support-user
+// PostSupportContext Requests can only be made using a Bearer Token.
+// Up to 20 targetUserIds are sent in one request and a failure returns a nil container.
+// More are sent in chunks of 20, waiting for the rate limit to reset until ctx is done; the
+// container then always holds the count of the chunks sent, and each failed or unsent chunk
+// is joined into the error as a SupportChunkError.
+func (supporterService *SupporterService) PostSupportContext(ctx context.Context, targetUserIds []string) (*PostSupportContainer, *ErrorResponse, error) {
+	if len(targetUserIds) <= supportTargetUserIdsLimit {
+		return supporterService.postSupport(targetUserIds)
+	}
+	addedCount, errorResponse, err := supporterService.supportInChunks(ctx, "PostSupport", targetUserIds, func(chunk []string) (int, *ErrorResponse, error) {
+		container, errorResponse, err := supporterService.postSupport(chunk)
+		if err != nil {
+			return 0, errorResponse, err
+		}
+		return container.AddedCount, nil, nil
+	})
+	return &PostSupportContainer{AddedCount: addedCount}, errorResponse, err
+}
+
+// DeleteSupport https://apiv2-doc.twitcasting.tv/#support-user
+// DeleteSupport Requests can only be made using a Bearer Token.
+// See DeleteSupportContext for more than 20 targetUserIds.
+func (supporterService *SupporterService) DeleteSupport(targetUserIds []string) (*DeleteSupportContainer, *ErrorResponse, error) {
+	return supporterService.DeleteSupportContext(context.Background(), targetUserIds)
+}
+
+// DeleteSupportContext https://apiv2-doc.twitcasting.tv/#support-user
+// DeleteSupportContext Requests can only be made using a Bearer Token.
+// Up to 20 targetUserIds are sent in one request and a failure returns a nil container.
+// More are sent in chunks of 20, waiting for the rate limit to reset until ctx is done; the
+// container then always holds the count of the chunks sent, and each failed or unsent chunk
+// is joined into the error as a SupportChunkError.
+func (supporterService *SupporterService) DeleteSupportContext(ctx context.Context, targetUserIds []string) (*DeleteSupportContainer, *ErrorResponse, error) {
+	if len(targetUserIds) <= supportTargetUserIdsLimit {
+		return supporterService.deleteSupport(targetUserIds)
+	}
+	removedCount, errorResponse, err := supporterService.supportInChunks(ctx, "DeleteSupport", targetUserIds, func(chunk []string) (int, *ErrorResponse, error) {
+		container, errorResponse, err := supporterService.deleteSupport(chunk)
+		if err != nil {
+			return 0, errorResponse, err
+		}
+		return container.RemovedCount, nil, nil
+	})
+	return &DeleteSupportContainer{RemovedCount: removedCount}, errorResponse, err
+}
+
+// supportInChunks sends every chunk, waiting for the rate limit to reset when it is exhausted, and sums the counts.
+// The failed chunks, and those left unsent once ctx is done, are joined into the returned error and the first
+// ErrorResponse is returned.
+func (supporterService *SupporterService) supportInChunks(ctx context.Context, name string, targetUserIds []string, send func(chunk []string) (int, *ErrorResponse, error)) (int, *ErrorResponse, error) {
+	logger := *supporterService.Logger
+	count := 0
+	var firstErrorResponse *ErrorResponse
+	var errs []error
+	for offset := 0; offset < len(targetUserIds); offset += supportTargetUserIdsLimit {
+		chunk := targetUserIds[offset:min(offset+supportTargetUserIdsLimit, len(targetUserIds))]
+		err := ctx.Err()
+		if err == nil {
+			err = supporterService.Client.waitRateLimit(ctx)
+		}
+		var errorResponse *ErrorResponse
+		var chunkCount int
+		if err == nil {
+			chunkCount, errorResponse, err = send(chunk)
+		}
+		if err != nil {
+			logger.Warn("chunk failed for "+name, offset, err)
+			if firstErrorResponse == nil {
+				firstErrorResponse = errorResponse
+			}
+			errs = append(errs, &SupportChunkError{Offset: offset, TargetUserIds: chunk, ErrorResponse: errorResponse, Err: err})
+			continue
+		}
+		count += chunkCount
+	}
+	return count, firstErrorResponse, errors.Join(errs...)
+}
+
+func (supporterService *SupporterService) postSupport(targetUserIds []string) (*PostSupportContainer, *ErrorResponse, error) {
 	logger := *supporterService.Logger
 	response, err := supporterService.Client.put("/support", map[string]interface{}{"target_user_ids": targetUserIds}, true)
 	if err != nil {
@@ -105,9 +221,7 @@ func (supporterService *SupporterService) PostSupport(targetUserIds []string) (*
 	}
 }
 
-// DeleteSupport https://apiv2-doc.twitcasting.tv/#support-user
-// DeleteSupport Requests can only be made using a Bearer Token.
-func (supporterService *SupporterService) DeleteSupport(targetUserIds []string) (*DeleteSupportContainer, *ErrorResponse, error) {
+func (supporterService *SupporterService) deleteSupport(targetUserIds []string) (*DeleteSupportContainer, *ErrorResponse, error) {
 	logger := *supporterService.Logger
 	response, err := supporterService.Client.put("/unsupport", map[string]interface{}{"target_user_ids": targetUserIds}, true)
 	if err != nil {
@@ -138,8 +252,17 @@ func (supporterService *SupporterService) DeleteSupport(targetUserIds []string)
 
 // GetSupportingList https://apiv2-doc.twitcasting.tv/#supporting-list
 func (supporterService *SupporterService) GetSupportingList(userId string, limit int, offset int, useBearerToken bool) (*SupporterListContainer, *ErrorResponse, error) {
+	return supporterService.GetSupportingListWith(GetSupportingListRequest{UserId: userId, Limit: limit, Offset: offset, UseBearerToken: useBearerToken})
+}
+
+// GetSupportingListWith https://apiv2-doc.twitcasting.tv/#supporting-list
+func (supporterService *SupporterService) GetSupportingListWith(request GetSupportingListRequest) (*SupporterListContainer, *ErrorResponse, error) {
 	logger := *supporterService.Logger
-	response, err := supporterService.Client.get(fmt.Sprintf("/users/%v/supporting?limit=%v&offset=%v", userId, limit, offset), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetSupportingList", err)
+		return nil, nil, err
+	}
+	response, err := supporterService.Client.get(fmt.Sprintf("/users/%v/supporting?%v", request.UserId, request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for GetSupportingList", err)
 		return nil, nil, err
@@ -168,8 +291,17 @@ func (supporterService *SupporterService) GetSupportingList(userId string, limit
 
 // GetSupporterList https://apiv2-doc.twitcasting.tv/#supporter-list
 func (supporterService *SupporterService) GetSupporterList(userId string, limit int, offset int, sort string, useBearerToken bool) (*SupporterListContainer, *ErrorResponse, error) {
+	return supporterService.GetSupporterListWith(GetSupporterListRequest{UserId: userId, Limit: limit, Offset: offset, Sort: SupporterSort(sort), UseBearerToken: useBearerToken})
+}
+
+// GetSupporterListWith https://apiv2-doc.twitcasting.tv/#supporter-list
+func (supporterService *SupporterService) GetSupporterListWith(request GetSupporterListRequest) (*SupporterListContainer, *ErrorResponse, error) {
 	logger := *supporterService.Logger
-	response, err := supporterService.Client.get(fmt.Sprintf("/users/%v/supporters?limit=%v&offset=%v&sort=%v", userId, limit, offset, sort), useBearerToken)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetSupporterList", err)
+		return nil, nil, err
+	}
+	response, err := supporterService.Client.get(fmt.Sprintf("/users/%v/supporters?%v", request.UserId, request.query().Encode()), request.UseBearerToken)
 	if err != nil {
 		logger.Error("request failed for GetSupporterList", err)
 		return nil, nil, err
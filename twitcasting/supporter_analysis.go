@@ -0,0 +1,284 @@
+package twitcasting
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SupporterOverlapPair is the supporters shared by two users.
+type SupporterOverlapPair struct {
+	UserId      string          `json:"user_id"`
+	OtherUserId string          `json:"other_user_id"`
+	Shared      []SupporterUser `json:"shared"`
+	// Jaccard is the number of shared supporters divided by the number of supporters of either user.
+	Jaccard float64 `json:"jaccard"`
+}
+
+type SupporterOverlap struct {
+	UserIds []string `json:"user_ids"`
+	// SupporterCounts maps each user id to its number of supporters.
+	SupporterCounts map[string]int         `json:"supporter_counts"`
+	Pairs           []SupporterOverlapPair `json:"pairs"`
+	// Common is the supporters of every user.
+	Common []SupporterUser `json:"common"`
+}
+
+// SupportRelation tells whether UserId and OtherUserId support each other.
+type SupportRelation struct {
+	UserId      string `json:"user_id"`
+	OtherUserId string `json:"other_user_id"`
+	// Supports is true when UserId supports OtherUserId.
+	Supports bool `json:"supports"`
+	// SupportedBy is true when OtherUserId supports UserId.
+	SupportedBy bool `json:"supported_by"`
+}
+
+func (supportRelation SupportRelation) Mutual() bool {
+	return supportRelation.Supports && supportRelation.SupportedBy
+}
+
+// SupporterAnalyzer compares the supporters of several users. Supporter and supporting lists and
+// supporting statuses are fetched at most Concurrency at a time and cached until Reset.
+type SupporterAnalyzer struct {
+	SupporterService *SupporterService
+	Concurrency      int
+	UseBearerToken   bool
+
+	mu         sync.Mutex
+	supporters map[string][]SupporterUser
+	supporting map[string][]SupporterUser
+	statuses   map[[2]string]bool
+}
+
+func CreateSupporterAnalyzer(supporterService *SupporterService) *SupporterAnalyzer {
+	return &SupporterAnalyzer{
+		SupporterService: supporterService,
+		Concurrency:      4,
+	}
+}
+
+// Reset drops the cached lists and statuses.
+func (supporterAnalyzer *SupporterAnalyzer) Reset() {
+	supporterAnalyzer.mu.Lock()
+	defer supporterAnalyzer.mu.Unlock()
+	supporterAnalyzer.supporters = nil
+	supporterAnalyzer.supporting = nil
+	supporterAnalyzer.statuses = nil
+}
+
+// Supporters returns the users supporting userId, fetched with SnapshotSupporters.
+func (supporterAnalyzer *SupporterAnalyzer) Supporters(ctx context.Context, userId string) ([]SupporterUser, error) {
+	supporterAnalyzer.mu.Lock()
+	users, ok := supporterAnalyzer.supporters[userId]
+	supporterAnalyzer.mu.Unlock()
+	if ok {
+		return users, nil
+	}
+	snapshot, err := supporterAnalyzer.SupporterService.SnapshotSupporters(ctx, userId, supporterAnalyzer.UseBearerToken)
+	if err != nil {
+		return nil, err
+	}
+	supporterAnalyzer.mu.Lock()
+	defer supporterAnalyzer.mu.Unlock()
+	if supporterAnalyzer.supporters == nil {
+		supporterAnalyzer.supporters = map[string][]SupporterUser{}
+	}
+	supporterAnalyzer.supporters[userId] = snapshot.Supporters
+	return snapshot.Supporters, nil
+}
+
+// Supporting returns the users supported by userId, paging through GetSupportingList.
+func (supporterAnalyzer *SupporterAnalyzer) Supporting(ctx context.Context, userId string) ([]SupporterUser, error) {
+	supporterAnalyzer.mu.Lock()
+	users, ok := supporterAnalyzer.supporting[userId]
+	supporterAnalyzer.mu.Unlock()
+	if ok {
+		return users, nil
+	}
+	users = []SupporterUser{}
+	seen := map[string]struct{}{}
+	for offset := 0; ; offset += supporterListPageLimit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, _, err := supporterAnalyzer.SupporterService.GetSupportingListWith(GetSupportingListRequest{
+			UserId:         userId,
+			Limit:          supporterListPageLimit,
+			Offset:         offset,
+			UseBearerToken: supporterAnalyzer.UseBearerToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range page.Users() {
+			if _, ok := seen[user.Id]; !ok {
+				seen[user.Id] = struct{}{}
+				users = append(users, user)
+			}
+		}
+		if len(page.Users()) < supporterListPageLimit || offset+supporterListPageLimit >= page.Total {
+			break
+		}
+	}
+	supporterAnalyzer.mu.Lock()
+	defer supporterAnalyzer.mu.Unlock()
+	if supporterAnalyzer.supporting == nil {
+		supporterAnalyzer.supporting = map[string][]SupporterUser{}
+	}
+	supporterAnalyzer.supporting[userId] = users
+	return users, nil
+}
+
+// MutualSupporters returns the supporters of userId that userId supports back, sorted by id.
+func (supporterAnalyzer *SupporterAnalyzer) MutualSupporters(ctx context.Context, userId string) ([]SupporterUser, error) {
+	supporters, err := supporterAnalyzer.Supporters(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	supporting, err := supporterAnalyzer.Supporting(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	supported := map[string]struct{}{}
+	for _, user := range supporting {
+		supported[user.Id] = struct{}{}
+	}
+	var mutual []SupporterUser
+	for _, user := range supporters {
+		if _, ok := supported[user.Id]; ok {
+			mutual = append(mutual, user)
+		}
+	}
+	sortSupporterUsers(mutual)
+	return mutual, nil
+}
+
+// Overlap fetches the supporters of every user and reports the supporters shared by each pair
+// and by all of them. Pairs are in the order of userIds.
+func (supporterAnalyzer *SupporterAnalyzer) Overlap(ctx context.Context, userIds ...string) (*SupporterOverlap, error) {
+	userIds = uniqueIds(userIds)
+	lists := make([][]SupporterUser, len(userIds))
+	errs := make([]error, len(userIds))
+	runConcurrently(ctx, len(userIds), supporterAnalyzer.Concurrency, func(i int) {
+		lists[i], errs[i] = supporterAnalyzer.Supporters(ctx, userIds[i])
+	})
+	if err := errors.Join(append(errs, ctx.Err())...); err != nil {
+		return nil, err
+	}
+
+	overlap := &SupporterOverlap{UserIds: userIds, SupporterCounts: map[string]int{}}
+	sets := make([]map[string]SupporterUser, len(userIds))
+	for i, users := range lists {
+		overlap.SupporterCounts[userIds[i]] = len(users)
+		sets[i] = map[string]SupporterUser{}
+		for _, user := range users {
+			sets[i][user.Id] = user
+		}
+	}
+	for i := range userIds {
+		for j := i + 1; j < len(userIds); j++ {
+			pair := SupporterOverlapPair{UserId: userIds[i], OtherUserId: userIds[j], Shared: []SupporterUser{}}
+			for id, user := range sets[i] {
+				if _, ok := sets[j][id]; ok {
+					pair.Shared = append(pair.Shared, user)
+				}
+			}
+			sortSupporterUsers(pair.Shared)
+			if union := len(sets[i]) + len(sets[j]) - len(pair.Shared); union > 0 {
+				pair.Jaccard = float64(len(pair.Shared)) / float64(union)
+			}
+			overlap.Pairs = append(overlap.Pairs, pair)
+		}
+	}
+	overlap.Common = []SupporterUser{}
+	if len(sets) > 0 {
+		for id, user := range sets[0] {
+			common := true
+			for _, set := range sets[1:] {
+				if _, ok := set[id]; !ok {
+					common = false
+					break
+				}
+			}
+			if common {
+				overlap.Common = append(overlap.Common, user)
+			}
+		}
+	}
+	sortSupporterUsers(overlap.Common)
+	return overlap, nil
+}
+
+// Relations checks with GetSupportingStatus whether each pair of userIds support each other.
+// Relations are in the order of userIds.
+func (supporterAnalyzer *SupporterAnalyzer) Relations(ctx context.Context, userIds ...string) ([]SupportRelation, error) {
+	userIds = uniqueIds(userIds)
+	var checks [][2]string
+	for _, userId := range userIds {
+		for _, targetUserId := range userIds {
+			if userId != targetUserId {
+				checks = append(checks, [2]string{userId, targetUserId})
+			}
+		}
+	}
+	supporting := make([]bool, len(checks))
+	errs := make([]error, len(checks))
+	runConcurrently(ctx, len(checks), supporterAnalyzer.Concurrency, func(i int) {
+		supporting[i], errs[i] = supporterAnalyzer.isSupporting(checks[i][0], checks[i][1])
+	})
+	if err := errors.Join(append(errs, ctx.Err())...); err != nil {
+		return nil, err
+	}
+
+	statuses := map[[2]string]bool{}
+	for i, check := range checks {
+		statuses[check] = supporting[i]
+	}
+	var relations []SupportRelation
+	for i := range userIds {
+		for j := i + 1; j < len(userIds); j++ {
+			relations = append(relations, SupportRelation{
+				UserId:      userIds[i],
+				OtherUserId: userIds[j],
+				Supports:    statuses[[2]string{userIds[i], userIds[j]}],
+				SupportedBy: statuses[[2]string{userIds[j], userIds[i]}],
+			})
+		}
+	}
+	return relations, nil
+}
+
+func (supporterAnalyzer *SupporterAnalyzer) isSupporting(userId string, targetUserId string) (bool, error) {
+	key := [2]string{userId, targetUserId}
+	supporterAnalyzer.mu.Lock()
+	isSupporting, ok := supporterAnalyzer.statuses[key]
+	supporterAnalyzer.mu.Unlock()
+	if ok {
+		return isSupporting, nil
+	}
+	status, _, err := supporterAnalyzer.SupporterService.GetSupportingStatus(userId, targetUserId, supporterAnalyzer.UseBearerToken)
+	if err != nil {
+		return false, err
+	}
+	supporterAnalyzer.mu.Lock()
+	defer supporterAnalyzer.mu.Unlock()
+	if supporterAnalyzer.statuses == nil {
+		supporterAnalyzer.statuses = map[[2]string]bool{}
+	}
+	supporterAnalyzer.statuses[key] = status.IsSupporting
+	return status.IsSupporting, nil
+}
+
+// uniqueIds drops the repeated ids, keeping the order of their first appearance.
+func uniqueIds(ids []string) []string {
+	seen := map[string]struct{}{}
+	var unique []string
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,92 @@
+package twitcasting_test
+
+import (
+	"context"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func createTestSupporterAnalyzer(t *testing.T) (*twitcasting.SupporterAnalyzer, *fakeSupportServer) {
+	fake := &fakeSupportServer{supporters: map[string][]string{
+		"a": {"1", "2", "3", "b", "c"},
+		"b": {"2", "3", "4", "a"},
+		"c": {"3", "5"},
+	}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	return twitcasting.CreateSupporterAnalyzer(locator.Supporter), fake
+}
+
+func TestSupporterAnalyzerOverlap(t *testing.T) {
+	analyzer, fake := createTestSupporterAnalyzer(t)
+
+	overlap, err := analyzer.Overlap(context.Background(), "a", "b", "c", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, overlap.UserIds)
+	assert.Equal(t, map[string]int{"a": 5, "b": 4, "c": 2}, overlap.SupporterCounts)
+	if assert.Len(t, overlap.Pairs, 3) {
+		assert.Equal(t, "a", overlap.Pairs[0].UserId)
+		assert.Equal(t, "b", overlap.Pairs[0].OtherUserId)
+		assert.Equal(t, []string{"2", "3"}, supporterIds(overlap.Pairs[0].Shared))
+		assert.InDelta(t, 2.0/7.0, overlap.Pairs[0].Jaccard, 1e-9)
+		assert.Equal(t, []string{"3"}, supporterIds(overlap.Pairs[1].Shared))
+		assert.Equal(t, "c", overlap.Pairs[2].OtherUserId)
+		assert.InDelta(t, 1.0/5.0, overlap.Pairs[2].Jaccard, 1e-9)
+	}
+	assert.Equal(t, []string{"3"}, supporterIds(overlap.Common))
+
+	// the supporter lists are cached until Reset
+	requests := fake.requests
+	_, err = analyzer.Overlap(context.Background(), "a", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, requests, fake.requests)
+	analyzer.Reset()
+	_, err = analyzer.Overlap(context.Background(), "a", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, requests+2, fake.requests)
+}
+
+func TestSupporterAnalyzerRelations(t *testing.T) {
+	analyzer, fake := createTestSupporterAnalyzer(t)
+
+	relations, err := analyzer.Relations(context.Background(), "a", "b", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, []twitcasting.SupportRelation{
+		{UserId: "a", OtherUserId: "b", Supports: true, SupportedBy: true},
+		{UserId: "a", OtherUserId: "c", Supports: false, SupportedBy: true},
+		{UserId: "b", OtherUserId: "c", Supports: false, SupportedBy: false},
+	}, relations)
+	assert.True(t, relations[0].Mutual())
+	assert.False(t, relations[1].Mutual())
+	assert.Equal(t, 6, fake.requests)
+
+	_, err = analyzer.Relations(context.Background(), "c", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, fake.requests)
+}
+
+func TestSupporterAnalyzerMutualSupporters(t *testing.T) {
+	analyzer, _ := createTestSupporterAnalyzer(t)
+
+	mutual, err := analyzer.MutualSupporters(context.Background(), "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, supporterIds(mutual))
+
+	supporting, err := analyzer.Supporting(context.Background(), "3")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, supporterIds(supporting))
+}
+
+func TestSupporterAnalyzerOverlapCanceled(t *testing.T) {
+	analyzer, _ := createTestSupporterAnalyzer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	overlap, err := analyzer.Overlap(ctx, "a", "b")
+	assert.Nil(t, overlap)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,168 @@
+package twitcasting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"time"
+)
+
+// supporterListPageLimit is the maximum limit accepted by the supporting and supporter list endpoints.
+const supporterListPageLimit = 20
+
+// SupporterSnapshot is the full supporter list of a user at Time.
+type SupporterSnapshot struct {
+	UserId     string          `json:"user_id"`
+	Time       time.Time       `json:"time"`
+	Total      int             `json:"total"`
+	Supporters []SupporterUser `json:"supporters"`
+}
+
+type SupporterPointChange struct {
+	User             SupporterUser `json:"user"`
+	PointBefore      int           `json:"point_before"`
+	PointAfter       int           `json:"point_after"`
+	TotalPointBefore int           `json:"total_point_before"`
+	TotalPointAfter  int           `json:"total_point_after"`
+}
+
+type SupporterDiff struct {
+	Added        []SupporterUser        `json:"added"`
+	Removed      []SupporterUser        `json:"removed"`
+	PointChanges []SupporterPointChange `json:"point_changes"`
+}
+
+// SnapshotSupporters pages through GetSupporterList until every supporter of userId is fetched.
+// Users listed twice because the list changed while paging are kept once. A supporter leaving
+// while paging shifts the later pages back, so a remaining supporter can be missed; check the
+// removals of a diff with VerifyRemovedSupporters.
+func (supporterService *SupporterService) SnapshotSupporters(ctx context.Context, userId string, useBearerToken bool) (*SupporterSnapshot, error) {
+	snapshot := &SupporterSnapshot{UserId: userId, Time: time.Now()}
+	seen := map[string]struct{}{}
+	for offset := 0; ; offset += supporterListPageLimit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, _, err := supporterService.GetSupporterListWith(GetSupporterListRequest{
+			UserId:         userId,
+			Limit:          supporterListPageLimit,
+			Offset:         offset,
+			Sort:           SupporterSortNew,
+			UseBearerToken: useBearerToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Total = page.Total
+		for _, user := range page.Users() {
+			if _, ok := seen[user.Id]; !ok {
+				seen[user.Id] = struct{}{}
+				snapshot.Supporters = append(snapshot.Supporters, user)
+			}
+		}
+		if len(page.Users()) < supporterListPageLimit || offset+supporterListPageLimit >= page.Total {
+			return snapshot, nil
+		}
+	}
+}
+
+// DiffSupporterSnapshots reports the supporters added, removed and with changed points from before to after.
+// A nil snapshot has no supporters. Each list is sorted by user id.
+func DiffSupporterSnapshots(before *SupporterSnapshot, after *SupporterSnapshot) SupporterDiff {
+	var beforeSupporters, afterSupporters []SupporterUser
+	if before != nil {
+		beforeSupporters = before.Supporters
+	}
+	if after != nil {
+		afterSupporters = after.Supporters
+	}
+	previous := map[string]SupporterUser{}
+	for _, user := range beforeSupporters {
+		previous[user.Id] = user
+	}
+	diff := SupporterDiff{}
+	current := map[string]struct{}{}
+	for _, user := range afterSupporters {
+		current[user.Id] = struct{}{}
+		old, ok := previous[user.Id]
+		if !ok {
+			diff.Added = append(diff.Added, user)
+		} else if old.Point != user.Point || old.TotalPoint != user.TotalPoint {
+			diff.PointChanges = append(diff.PointChanges, SupporterPointChange{
+				User:             user,
+				PointBefore:      old.Point,
+				PointAfter:       user.Point,
+				TotalPointBefore: old.TotalPoint,
+				TotalPointAfter:  user.TotalPoint,
+			})
+		}
+	}
+	for _, user := range beforeSupporters {
+		if _, ok := current[user.Id]; !ok {
+			diff.Removed = append(diff.Removed, user)
+		}
+	}
+	sortSupporterUsers(diff.Added)
+	sortSupporterUsers(diff.Removed)
+	sort.Slice(diff.PointChanges, func(i, j int) bool {
+		return compareIds(diff.PointChanges[i].User.Id, diff.PointChanges[j].User.Id) < 0
+	})
+	return diff
+}
+
+// VerifyRemovedSupporters checks each user of diff.Removed with GetSupportingStatus and drops
+// those still supporting userId, which the snapshot missed while paging.
+func (supporterService *SupporterService) VerifyRemovedSupporters(ctx context.Context, userId string, diff *SupporterDiff, useBearerToken bool) error {
+	var removed []SupporterUser
+	for _, user := range diff.Removed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		status, _, err := supporterService.GetSupportingStatus(user.Id, userId, useBearerToken)
+		if err != nil {
+			return err
+		}
+		if !status.IsSupporting {
+			removed = append(removed, user)
+		}
+	}
+	diff.Removed = removed
+	return nil
+}
+
+func sortSupporterUsers(users []SupporterUser) {
+	sort.Slice(users, func(i, j int) bool {
+		return compareIds(users[i].Id, users[j].Id) < 0
+	})
+}
+
+// Save writes the snapshot as JSON to path, replacing the file atomically.
+func (supporterSnapshot *SupporterSnapshot) Save(path string) error {
+	data, err := json.MarshalIndent(supporterSnapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err = os.WriteFile(temporaryPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
+
+// LoadSupporterSnapshot reads a snapshot written by Save. It returns nil without error when path does not exist.
+func LoadSupporterSnapshot(path string) (*SupporterSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := new(SupporterSnapshot)
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
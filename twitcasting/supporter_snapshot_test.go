@@ -0,0 +1,157 @@
+package twitcasting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSupportServer answers the supporter and supporting lists and the supporting status
+// from supporters, which maps a user id to the ids supporting that user.
+type fakeSupportServer struct {
+	mu         sync.Mutex
+	supporters map[string][]string
+	points     map[string]int
+	requests   int
+}
+
+func (fake *fakeSupportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests++
+	parts := strings.Split(r.URL.Path, "/")
+	userId := parts[2]
+	query := r.URL.Query()
+	var body []byte
+	switch parts[3] {
+	case "supporting_status":
+		isSupporting := false
+		for _, id := range fake.supporters[query.Get("target_user_id")] {
+			isSupporting = isSupporting || id == userId
+		}
+		body, _ = json.Marshal(twitcasting.SupportingStatusContainer{IsSupporting: isSupporting})
+	case "supporters", "supporting":
+		var ids []string
+		if parts[3] == "supporters" {
+			ids = fake.supporters[userId]
+		} else {
+			for targetUserId, supporterIds := range fake.supporters {
+				for _, id := range supporterIds {
+					if id == userId {
+						ids = append(ids, targetUserId)
+					}
+				}
+			}
+			ids = sortedCopy(ids)
+		}
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		page := ids[min(offset, len(ids)):min(offset+limit, len(ids))]
+		container := twitcasting.SupporterListContainer{Total: len(ids), Supporting: []twitcasting.SupporterUser{}}
+		for _, id := range page {
+			user := twitcasting.SupporterUser{Id: id, ScreenId: "user" + id, Point: fake.points[id], TotalPoint: fake.points[id] * 2}
+			if parts[3] == "supporters" {
+				container.Supporters = append(container.Supporters, user)
+			} else {
+				container.Supporting = append(container.Supporting, user)
+			}
+		}
+		body, _ = json.Marshal(container)
+	}
+	_, _ = w.Write(body)
+}
+
+// sortedCopy sorts numeric ids by value.
+func sortedCopy(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) < len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+func supporterIds(users []twitcasting.SupporterUser) []string {
+	var ids []string
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+	return ids
+}
+
+func TestSnapshotSupporters(t *testing.T) {
+	var ids []string
+	for id := 1; id <= 45; id++ {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	fake := &fakeSupportServer{supporters: map[string][]string{"owner": ids}, points: map[string]int{"3": 10}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	before, err := locator.Supporter.SnapshotSupporters(context.Background(), "owner", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 45, before.Total)
+	assert.Equal(t, ids, supporterIds(before.Supporters))
+	assert.Equal(t, 3, fake.requests)
+
+	path := filepath.Join(t.TempDir(), "supporters.json")
+	loaded, err := twitcasting.LoadSupporterSnapshot(path)
+	assert.Nil(t, err)
+	assert.Nil(t, loaded)
+	assert.Nil(t, before.Save(path))
+	loaded, err = twitcasting.LoadSupporterSnapshot(path)
+	assert.Nil(t, err)
+	assert.Equal(t, before.Supporters, loaded.Supporters)
+	assert.True(t, before.Time.Equal(loaded.Time))
+
+	fake.supporters["owner"] = append(append(ids[:4:4], ids[5:]...), "46", "47")
+	fake.points["3"] = 15
+	after, err := locator.Supporter.SnapshotSupporters(context.Background(), "owner", false)
+	assert.Nil(t, err)
+
+	diff := twitcasting.DiffSupporterSnapshots(loaded, after)
+	assert.Equal(t, []string{"46", "47"}, supporterIds(diff.Added))
+	assert.Equal(t, []string{"5"}, supporterIds(diff.Removed))
+	assert.Equal(t, 1, len(diff.PointChanges))
+	assert.Equal(t, twitcasting.SupporterPointChange{User: after.Supporters[2], PointBefore: 10, PointAfter: 15, TotalPointBefore: 20, TotalPointAfter: 30}, diff.PointChanges[0])
+}
+
+func TestDiffSupporterSnapshotsNil(t *testing.T) {
+	after := &twitcasting.SupporterSnapshot{Supporters: []twitcasting.SupporterUser{{Id: "2"}, {Id: "1"}}}
+
+	diff := twitcasting.DiffSupporterSnapshots(nil, after)
+	assert.Equal(t, []string{"1", "2"}, supporterIds(diff.Added))
+	assert.Empty(t, diff.Removed)
+
+	diff = twitcasting.DiffSupporterSnapshots(after, nil)
+	assert.Empty(t, diff.Added)
+	assert.Equal(t, []string{"1", "2"}, supporterIds(diff.Removed))
+}
+
+func TestVerifyRemovedSupporters(t *testing.T) {
+	fake := &fakeSupportServer{supporters: map[string][]string{"owner": {"1", "3"}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	// "3" was skipped while paging and only "2" really left
+	before := &twitcasting.SupporterSnapshot{Supporters: []twitcasting.SupporterUser{{Id: "1"}, {Id: "2"}, {Id: "3"}}}
+	after := &twitcasting.SupporterSnapshot{Supporters: []twitcasting.SupporterUser{{Id: "1"}}}
+	diff := twitcasting.DiffSupporterSnapshots(before, after)
+	assert.Equal(t, []string{"2", "3"}, supporterIds(diff.Removed))
+	assert.Nil(t, locator.Supporter.VerifyRemovedSupporters(context.Background(), "owner", &diff, false))
+	assert.Equal(t, []string{"2"}, supporterIds(diff.Removed))
+	assert.Equal(t, 2, fake.requests)
+}
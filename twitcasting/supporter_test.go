@@ -1,11 +1,18 @@
 package twitcasting_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"github.com/amemiya/twitcasting-go-auth/twitcasting"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestGetSupportingStatus(t *testing.T) {
@@ -106,6 +113,107 @@ func TestDeleteSupport(t *testing.T) {
 	server.Close()
 }
 
+// fakeSupportChunkServer answers support and unsupport requests with the number of ids,
+// failing the chunks containing failId.
+type fakeSupportChunkServer struct {
+	failId string
+	reset  time.Time
+	chunks [][]string
+	sentAt []time.Time
+}
+
+func (fake *fakeSupportChunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		TargetUserIds []string `json:"target_user_ids"`
+	}{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	fake.chunks = append(fake.chunks, body.TargetUserIds)
+	fake.sentAt = append(fake.sentAt, time.Now())
+	if !fake.reset.IsZero() {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(fake.reset.Unix(), 10))
+	}
+	var response interface{}
+	if slices.Contains(body.TargetUserIds, fake.failId) {
+		w.WriteHeader(http.StatusBadRequest)
+		response = twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 404, Message: "Not Found"}}
+	} else if r.URL.Path == "/support" {
+		response = twitcasting.PostSupportContainer{AddedCount: len(body.TargetUserIds)}
+	} else {
+		response = twitcasting.DeleteSupportContainer{RemovedCount: len(body.TargetUserIds)}
+	}
+	data, _ := json.Marshal(response)
+	_, _ = w.Write(data)
+}
+
+func supportTargetIds(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = "user" + strconv.Itoa(i)
+	}
+	return ids
+}
+
+func TestPostSupportInChunks(t *testing.T) {
+	fake := &fakeSupportChunkServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	ids := supportTargetIds(45)
+	postSupportResponse, errorResponse, err := locator.Supporter.PostSupport(ids)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, &twitcasting.PostSupportContainer{AddedCount: 45}, postSupportResponse)
+	assert.Equal(t, [][]string{ids[:20], ids[20:40], ids[40:]}, fake.chunks)
+}
+
+func TestDeleteSupportInChunksReportsFailedChunk(t *testing.T) {
+	fake := &fakeSupportChunkServer{failId: "user25"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+
+	ids := supportTargetIds(50)
+	deleteSupportResponse, errorResponse, err := locator.Supporter.DeleteSupport(ids)
+	assert.Equal(t, &twitcasting.DeleteSupportContainer{RemovedCount: 30}, deleteSupportResponse)
+	assert.Equal(t, &twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 404, Message: "Not Found"}}, errorResponse)
+	assert.Len(t, fake.chunks, 3)
+	var chunkError *twitcasting.SupportChunkError
+	if assert.True(t, errors.As(err, &chunkError)) {
+		assert.Equal(t, 20, chunkError.Offset)
+		assert.Equal(t, ids[20:40], chunkError.TargetUserIds)
+		assert.Equal(t, errorResponse, chunkError.ErrorResponse)
+		assert.Equal(t, "chunk of 20 target users from offset 20 failed: error response", chunkError.Error())
+	}
+}
+
+func TestPostSupportContextStopsWaitingForRateLimit(t *testing.T) {
+	fake := &fakeSupportChunkServer{reset: time.Now().Add(time.Hour)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the first chunk exhausts the rate limit and the others wait until ctx is done
+	ids := supportTargetIds(45)
+	postSupportResponse, errorResponse, err := locator.Supporter.PostSupportContext(ctx, ids)
+	assert.Equal(t, &twitcasting.PostSupportContainer{AddedCount: 20}, postSupportResponse)
+	assert.Nil(t, errorResponse)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, fake.chunks, 1)
+	var unsent []string
+	for _, chunkErr := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var chunkError *twitcasting.SupportChunkError
+		if assert.True(t, errors.As(chunkErr, &chunkError)) {
+			unsent = append(unsent, chunkError.TargetUserIds...)
+		}
+	}
+	assert.Equal(t, ids[20:], unsent)
+}
+
 func TestGetSupportingList(t *testing.T) {
 	expected := twitcasting.SupporterListContainer{
 		Total: 1000,
@@ -2,10 +2,14 @@ package twitcasting
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
 const baseUrl = "https://apiv2.twitcasting.tv"
@@ -32,6 +36,16 @@ type Client struct {
 	client              *http.Client
 	baseURL             string
 	basicAndBearerToken BasicAndBearerToken
+
+	rateLimitMu sync.Mutex
+	rateLimit   *RateLimit
+}
+
+// RateLimit is taken from the X-RateLimit-* headers of the latest API response.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
 }
 
 func (c *Client) SetClient(client *http.Client) {
@@ -58,8 +72,7 @@ func (c *Client) get(path string, useBearerToken bool) (*http.Response, error) {
 	} else {
 		request.Header.Set("Authorization", "Basic "+c.basicAndBearerToken.basic)
 	}
-	response, err := c.client.Do(request)
-	return response, err
+	return c.do(request)
 }
 
 func (c *Client) post(path string, requestBody interface{}, useBearerToken bool) (*http.Response, error) {
@@ -79,8 +92,7 @@ func (c *Client) post(path string, requestBody interface{}, useBearerToken bool)
 	} else {
 		request.Header.Set("Authorization", "Basic "+c.basicAndBearerToken.basic)
 	}
-	response, err := c.client.Do(request)
-	return response, err
+	return c.do(request)
 }
 
 func (c *Client) put(path string, requestBody interface{}, useBearerToken bool) (*http.Response, error) {
@@ -100,8 +112,7 @@ func (c *Client) put(path string, requestBody interface{}, useBearerToken bool)
 	} else {
 		request.Header.Set("Authorization", "Basic "+c.basicAndBearerToken.basic)
 	}
-	response, err := c.client.Do(request)
-	return response, err
+	return c.do(request)
 }
 
 func (c *Client) delete(path string, useBearerToken bool) (*http.Response, error) {
@@ -116,6 +127,51 @@ func (c *Client) delete(path string, useBearerToken bool) (*http.Response, error
 	} else {
 		request.Header.Set("Authorization", "Basic "+c.basicAndBearerToken.basic)
 	}
+	return c.do(request)
+}
+
+// do sends an API request and records its rate limit headers.
+func (c *Client) do(request *http.Request) (*http.Response, error) {
+	response, err := c.client.Do(request)
+	if err != nil {
+		return response, err
+	}
+	limit, limitErr := strconv.Atoi(response.Header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if limitErr == nil && remainingErr == nil && resetErr == nil {
+		c.rateLimitMu.Lock()
+		c.rateLimit = &RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+		c.rateLimitMu.Unlock()
+	}
+	return response, err
+}
+
+// RateLimit returns the rate limit of the latest API response. ok is false until a response had the headers.
+func (c *Client) RateLimit() (rateLimit RateLimit, ok bool) {
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	if c.rateLimit == nil {
+		return RateLimit{}, false
+	}
+	return *c.rateLimit, true
+}
+
+// waitRateLimit blocks until the rate limit resets when no request is remaining.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	rateLimit, ok := c.RateLimit()
+	if !ok || rateLimit.Remaining > 0 {
+		return nil
+	}
+	return sleepContext(ctx, time.Until(rateLimit.Reset))
+}
+
+// getUrl requests an absolute url outside the API, such as a hls playlist, without API headers.
+func (c *Client) getUrl(rawUrl string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
 	response, err := c.client.Do(request)
 	return response, err
 }
@@ -133,6 +189,7 @@ type ServiceLocator struct {
 	Category    *CategoryService
 	Comment     *CommentService
 	Gift        *GiftService
+	Hls         *HlsService
 	Movie       *MovieService
 	Search      *SearchService
 	Supporter   *SupporterService
@@ -156,6 +213,7 @@ func CreateServiceLocator(httpClient *http.Client, logger Logger, accessToken Ac
 		Category:    &CategoryService{Client: client, Logger: &logger},
 		Comment:     &CommentService{Client: client, Logger: &logger},
 		Gift:        &GiftService{Client: client, Logger: &logger},
+		Hls:         &HlsService{Client: client, Logger: &logger},
 		Movie:       &MovieService{Client: client, Logger: &logger},
 		Search:      &SearchService{Client: client, Logger: &logger},
 		Supporter:   &SupporterService{Client: client, Logger: &logger},
@@ -38,6 +38,7 @@ func CreateTestServiceLocator(httpClient *http.Client, urlParse string, accessTo
 		Category:    &twitcasting.CategoryService{Client: &client, Logger: &logger},
 		Comment:     &twitcasting.CommentService{Client: &client, Logger: &logger},
 		Gift:        &twitcasting.GiftService{Client: &client, Logger: &logger},
+		Hls:         &twitcasting.HlsService{Client: &client, Logger: &logger},
 		Movie:       &twitcasting.MovieService{Client: &client, Logger: &logger},
 		Search:      &twitcasting.SearchService{Client: &client, Logger: &logger},
 		Supporter:   &twitcasting.SupporterService{Client: &client, Logger: &logger},
@@ -109,6 +110,7 @@ func TestCreateServiceLocator(t *testing.T) {
 	assert.NotNil(t, locator.Category)
 	assert.NotNil(t, locator.Comment)
 	assert.NotNil(t, locator.Gift)
+	assert.NotNil(t, locator.Hls)
 	assert.NotNil(t, locator.Movie)
 	assert.NotNil(t, locator.Search)
 	assert.NotNil(t, locator.Supporter)
@@ -0,0 +1,164 @@
+package twitcasting
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ViewerSample struct {
+	Time             time.Time `json:"time"`
+	CurrentViewCount int       `json:"current_view_count"`
+	MaxViewCount     int       `json:"max_view_count"`
+	TotalViewCount   int       `json:"total_view_count"`
+}
+
+// RetentionPoint is the share of the peak audience watching at ElapsedSeconds after the first sample.
+type RetentionPoint struct {
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	Ratio          float64 `json:"ratio"`
+}
+
+type ViewerStats struct {
+	MovieId          string           `json:"movie_id"`
+	Start            time.Time        `json:"start"`
+	End              time.Time        `json:"end"`
+	SampleCount      int              `json:"sample_count"`
+	PeakViewCount    int              `json:"peak_view_count"`
+	PeakTime         time.Time        `json:"peak_time"`
+	AverageViewCount float64          `json:"average_view_count"`
+	MaxViewCount     int              `json:"max_view_count"`
+	TotalViewCount   int              `json:"total_view_count"`
+	Retention        []RetentionPoint `json:"retention"`
+}
+
+// ViewerSampler samples the view counts of a movie with GetMovie at a fixed interval.
+type ViewerSampler struct {
+	MovieService   *MovieService
+	MovieId        string
+	Interval       time.Duration
+	UseBearerToken bool
+
+	mu      sync.Mutex
+	samples []ViewerSample
+}
+
+// defaultViewerSamplerInterval is used by Run when Interval is not positive.
+const defaultViewerSamplerInterval = 30 * time.Second
+
+func CreateViewerSampler(movieService *MovieService, movieId string) *ViewerSampler {
+	return &ViewerSampler{
+		MovieService: movieService,
+		MovieId:      movieId,
+		Interval:     defaultViewerSamplerInterval,
+	}
+}
+
+// Run samples until the movie is no longer live and returns nil, or until ctx is done.
+// An Interval that is not positive samples every 30 seconds.
+func (viewerSampler *ViewerSampler) Run(ctx context.Context) error {
+	logger := *viewerSampler.MovieService.Logger
+	interval := viewerSampler.Interval
+	if interval <= 0 {
+		interval = defaultViewerSamplerInterval
+	}
+	for {
+		container, _, err := viewerSampler.MovieService.GetMovie(viewerSampler.MovieId, viewerSampler.UseBearerToken)
+		if err != nil {
+			logger.Warn("get movie failed for ViewerSampler", err)
+		} else {
+			if !container.Movie.IsLive {
+				return nil
+			}
+			viewerSampler.Add(ViewerSample{
+				Time:             time.Now(),
+				CurrentViewCount: container.Movie.CurrentViewCount,
+				MaxViewCount:     container.Movie.MaxViewCount,
+				TotalViewCount:   container.Movie.TotalViewCount,
+			})
+		}
+		if err = sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+func (viewerSampler *ViewerSampler) Add(sample ViewerSample) {
+	viewerSampler.mu.Lock()
+	defer viewerSampler.mu.Unlock()
+	viewerSampler.samples = append(viewerSampler.samples, sample)
+}
+
+func (viewerSampler *ViewerSampler) Samples() []ViewerSample {
+	viewerSampler.mu.Lock()
+	defer viewerSampler.mu.Unlock()
+	return append([]ViewerSample(nil), viewerSampler.samples...)
+}
+
+func (viewerSampler *ViewerSampler) Stats() ViewerStats {
+	samples := viewerSampler.Samples()
+	stats := ViewerStats{MovieId: viewerSampler.MovieId, SampleCount: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	stats.Start = samples[0].Time
+	stats.End = samples[len(samples)-1].Time
+	sum := 0
+	for _, sample := range samples {
+		sum += sample.CurrentViewCount
+		if sample.CurrentViewCount > stats.PeakViewCount || stats.PeakTime.IsZero() {
+			stats.PeakViewCount = sample.CurrentViewCount
+			stats.PeakTime = sample.Time
+		}
+		stats.MaxViewCount = max(stats.MaxViewCount, sample.MaxViewCount)
+		stats.TotalViewCount = max(stats.TotalViewCount, sample.TotalViewCount)
+	}
+	stats.AverageViewCount = float64(sum) / float64(len(samples))
+	for _, sample := range samples {
+		ratio := 0.0
+		if stats.PeakViewCount > 0 {
+			ratio = float64(sample.CurrentViewCount) / float64(stats.PeakViewCount)
+		}
+		stats.Retention = append(stats.Retention, RetentionPoint{
+			ElapsedSeconds: sample.Time.Sub(stats.Start).Seconds(),
+			Ratio:          ratio,
+		})
+	}
+	return stats
+}
+
+// WriteCSV writes one row per sample.
+func (viewerSampler *ViewerSampler) WriteCSV(w io.Writer) error {
+	samples := viewerSampler.Samples()
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"time", "elapsed_seconds", "current_view_count", "max_view_count", "total_view_count"})
+	if err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		err = writer.Write([]string{
+			sample.Time.Format(time.RFC3339),
+			strconv.FormatFloat(sample.Time.Sub(samples[0].Time).Seconds(), 'f', -1, 64),
+			strconv.Itoa(sample.CurrentViewCount),
+			strconv.Itoa(sample.MaxViewCount),
+			strconv.Itoa(sample.TotalViewCount),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the stats together with every sample.
+func (viewerSampler *ViewerSampler) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(struct {
+		Stats   ViewerStats    `json:"stats"`
+		Samples []ViewerSample `json:"samples"`
+	}{viewerSampler.Stats(), viewerSampler.Samples()})
+}
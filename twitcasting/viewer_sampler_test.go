@@ -0,0 +1,98 @@
+package twitcasting_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/amemiya/twitcasting-go-auth/twitcasting"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestViewerSamplerStats(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	sampler := twitcasting.CreateViewerSampler(nil, "100")
+	for i, count := range []int{2, 8, 10, 6, 4} {
+		sampler.Add(twitcasting.ViewerSample{Time: start.Add(time.Duration(i) * time.Minute), CurrentViewCount: count, MaxViewCount: 10, TotalViewCount: 20 + i})
+	}
+	stats := sampler.Stats()
+	assert.Equal(t, 5, stats.SampleCount)
+	assert.Equal(t, 10, stats.PeakViewCount)
+	assert.Equal(t, start.Add(2*time.Minute), stats.PeakTime)
+	assert.Equal(t, 6.0, stats.AverageViewCount)
+	assert.Equal(t, 24, stats.TotalViewCount)
+	assert.Equal(t, []twitcasting.RetentionPoint{
+		{ElapsedSeconds: 0, Ratio: 0.2},
+		{ElapsedSeconds: 60, Ratio: 0.8},
+		{ElapsedSeconds: 120, Ratio: 1},
+		{ElapsedSeconds: 180, Ratio: 0.6},
+		{ElapsedSeconds: 240, Ratio: 0.4},
+	}, stats.Retention)
+
+	var csv bytes.Buffer
+	assert.Nil(t, sampler.WriteCSV(&csv))
+	assert.Equal(t, "time,elapsed_seconds,current_view_count,max_view_count,total_view_count\n"+
+		"2024-05-01T20:00:00Z,0,2,10,20\n"+
+		"2024-05-01T20:01:00Z,60,8,10,21\n"+
+		"2024-05-01T20:02:00Z,120,10,10,22\n"+
+		"2024-05-01T20:03:00Z,180,6,10,23\n"+
+		"2024-05-01T20:04:00Z,240,4,10,24\n", csv.String())
+
+	var out bytes.Buffer
+	assert.Nil(t, sampler.WriteJSON(&out))
+	decoded := struct {
+		Stats   twitcasting.ViewerStats    `json:"stats"`
+		Samples []twitcasting.ViewerSample `json:"samples"`
+	}{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, 10, decoded.Stats.PeakViewCount)
+	assert.Equal(t, 5, len(decoded.Samples))
+
+	assert.Equal(t, twitcasting.ViewerStats{MovieId: "1"}, twitcasting.CreateViewerSampler(nil, "1").Stats())
+}
+
+func TestViewerSamplerRun(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		body, _ := json.Marshal(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "100", IsLive: polls <= 3, CurrentViewCount: polls * 2}})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	sampler := twitcasting.CreateViewerSampler(locator.Movie, "100")
+	sampler.Interval = time.Millisecond
+
+	assert.Nil(t, sampler.Run(context.Background()))
+	assert.Equal(t, 3, len(sampler.Samples()))
+	assert.Equal(t, 6, sampler.Stats().PeakViewCount)
+}
+
+func TestViewerSamplerRunWithoutInterval(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		body, _ := json.Marshal(twitcasting.MovieContainer{Movie: twitcasting.Movie{Id: "100", IsLive: true}})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
+	sampler := &twitcasting.ViewerSampler{MovieService: locator.Movie, MovieId: "100"}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, sampler.Run(ctx))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, polls)
+}
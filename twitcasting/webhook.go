@@ -37,8 +37,18 @@ type WebhookService ServiceBase
 // GetWebhookList https://apiv2-doc.twitcasting.tv/#get-webhook-list
 // GetWebhookList Requests can only be made using a Basic Token.
 func (webhookService *WebhookService) GetWebhookList(limit int, offset int) (*WebhookListContainer, *ErrorResponse, error) {
+	return webhookService.GetWebhookListWith(GetWebhookListRequest{Limit: limit, Offset: offset})
+}
+
+// GetWebhookListWith https://apiv2-doc.twitcasting.tv/#get-webhook-list
+// GetWebhookListWith Requests can only be made using a Basic Token.
+func (webhookService *WebhookService) GetWebhookListWith(request GetWebhookListRequest) (*WebhookListContainer, *ErrorResponse, error) {
 	logger := *webhookService.Logger
-	response, err := webhookService.Client.get(fmt.Sprintf("/webhooks?limit=%v&offset=%v", limit, offset), false)
+	if err := request.Validate(); err != nil {
+		logger.Error("validate request failed for GetWebhookList", err)
+		return nil, nil, err
+	}
+	response, err := webhookService.Client.get(fmt.Sprintf("/webhooks?%v", request.query().Encode()), false)
 	if err != nil {
 		logger.Error("request failed for GetWebhookList", err)
 		return nil, nil, err
@@ -16,20 +16,20 @@ func TestGetWebhookList(t *testing.T) {
 			{UserId: "7134775954", Event: "liveend"},
 		},
 	}
-	server := CreateTestSever(t, expected, "", url.Values{"limit": []string{"100"}, "offset": []string{"0"}}, false, http.StatusOK)
+	server := CreateTestSever(t, expected, "", url.Values{"limit": []string{"50"}, "offset": []string{"0"}}, false, http.StatusOK)
 	server.Start()
 	locator := CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
-	webhookResponse, errorResponse, err := locator.Webhook.GetWebhookList(100, 0)
+	webhookResponse, errorResponse, err := locator.Webhook.GetWebhookList(50, 0)
 	assert.Nil(t, err)
 	assert.Nil(t, errorResponse)
 	assert.Equal(t, &expected, webhookResponse)
 	server.Close()
 
 	expected2 := twitcasting.ErrorResponse{Error: twitcasting.Error{Code: 1000, Message: "Invalid token"}}
-	server = CreateTestSever(t, expected2, "", url.Values{"limit": []string{"100"}, "offset": []string{"0"}}, false, http.StatusBadRequest)
+	server = CreateTestSever(t, expected2, "", url.Values{"limit": []string{"50"}, "offset": []string{"0"}}, false, http.StatusBadRequest)
 	server.Start()
 	locator = CreateTestServiceLocator(&http.Client{}, server.URL, twitcasting.AccessToken{ClientId: "client", ClientSecret: "secret", Bearer: "bearer"})
-	webhookResponse, errorResponse, err = locator.Webhook.GetWebhookList(100, 0)
+	webhookResponse, errorResponse, err = locator.Webhook.GetWebhookList(50, 0)
 	assert.Nil(t, webhookResponse)
 	assert.NotNil(t, err)
 	assert.Equal(t, "error response", err.Error())